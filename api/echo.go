@@ -17,11 +17,15 @@ var (
 
 type API struct {
 	*User
+	*Maintenance
+	*Version
 }
 
-func NewAPI(user *User) *API {
+func NewAPI(user *User, maintenance *Maintenance, version *Version) *API {
 	return &API{
-		User: user,
+		User:        user,
+		Maintenance: maintenance,
+		Version:     version,
 	}
 }
 
@@ -79,6 +83,9 @@ func (api *API) Start(port int) error {
 
 	e.POST("/new", api.User.PostNewUser)
 	e.PUT("/reset", api.User.PutReset)
+	e.PUT("/metadata", api.User.PutMetadata)
+	e.PUT("/maintenance", api.Maintenance.PutMaintenance)
+	e.GET("/version", api.Version.GetVersion)
 
 	return e.Start(fmt.Sprintf(":%d", port))
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/separated-webshell/service"
+)
+
+type Maintenance struct {
+	*service.Maintenance
+	*validator.Validate
+}
+
+func NewMaintenance(m *service.Maintenance) *Maintenance {
+	return &Maintenance{
+		Maintenance: m,
+		Validate:    NewValidator(),
+	}
+}
+
+type putMaintenanceRequest struct {
+	APIKey  string `json:"key" validate:"required"`
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+func (m *Maintenance) PutMaintenance(c echo.Context) error {
+	req := putMaintenanceRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to bind request: %w", err))
+	}
+
+	err = m.Validate.Struct(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	if req.APIKey != apiKey {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
+	}
+
+	m.Maintenance.SetMaintenance(req.Enabled, req.Message)
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/mazrean/separated-webshell/domain"
 	"github.com/mazrean/separated-webshell/domain/values"
 	"github.com/mazrean/separated-webshell/service"
 )
@@ -32,6 +33,8 @@ type postNewUserRequest struct {
 	APIKey   string `json:"key" validate:"required"`
 	Name     string `json:"name" validate:"required"`
 	Password string `json:"cred" validate:"required"`
+	// Metadata annotations of the workspace(e.g. course ID). Optional.
+	Metadata map[string]string `json:"metadata"`
 }
 
 func (u *User) PostNewUser(c echo.Context) error {
@@ -60,10 +63,16 @@ func (u *User) PostNewUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
 
-	err = u.User.New(c.Request().Context(), userName, password)
+	err = u.User.New(c.Request().Context(), userName, password, domain.ContainerMetadata(req.Metadata))
 	if errors.Is(err, service.ErrUserExist) {
 		return echo.NewHTTPError(http.StatusBadRequest, "user already exist")
 	}
+	if errors.Is(err, service.ErrMaintenance) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "under maintenance")
+	}
+	if errors.Is(err, service.ErrInsufficientMemory) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "no memory available for a new workspace")
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to create user: %w", err))
 	}
@@ -107,3 +116,41 @@ func (u *User) PutReset(c echo.Context) error {
 
 	return nil
 }
+
+type putMetadataRequest struct {
+	APIKey   string            `json:"key" validate:"required"`
+	Name     string            `json:"name" validate:"required"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+func (u *User) PutMetadata(c echo.Context) error {
+	req := putMetadataRequest{}
+	err := c.Bind(&req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to bind request: %w", err))
+	}
+
+	err = u.Validate.Struct(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	if req.APIKey != apiKey {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
+	}
+
+	userName, err := values.NewUserName(req.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	err = u.User.SetMetadata(c.Request().Context(), userName, domain.ContainerMetadata(req.Metadata))
+	if errors.Is(err, service.ErrInvalidUser) {
+		return echo.NewHTTPError(http.StatusBadRequest, "no user")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Errorf("failed to set metadata: %w", err))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
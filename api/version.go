@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mazrean/separated-webshell/service"
+	"github.com/mazrean/separated-webshell/version"
+)
+
+type Version struct {
+	*service.Version
+}
+
+func NewVersion(v *service.Version) *Version {
+	return &Version{
+		Version: v,
+	}
+}
+
+type versionResponse struct {
+	Version             string `json:"version"`
+	GoVersion           string `json:"go_version"`
+	DockerSDKVersion    string `json:"docker_sdk_version"`
+	DockerDaemonVersion string `json:"docker_daemon_version"`
+	StorageDriver       string `json:"storage_driver"`
+	GitCommit           string `json:"git_commit"`
+	BuildTime           string `json:"build_time"`
+}
+
+func (v *Version) GetVersion(c echo.Context) error {
+	// the build versions are still useful when the daemon is down
+	daemonVersion, err := v.Version.DaemonVersion(c.Request().Context())
+	if err != nil {
+		c.Logger().Error(err)
+		daemonVersion = "unknown"
+	}
+
+	storageDriver, err := v.Version.StorageDriver(c.Request().Context())
+	if err != nil {
+		c.Logger().Error(err)
+		storageDriver = "unknown"
+	}
+
+	return c.JSON(http.StatusOK, versionResponse{
+		Version:             version.Version,
+		GoVersion:           version.GoVersion(),
+		DockerSDKVersion:    version.DockerSDKVersion(),
+		DockerDaemonVersion: daemonVersion,
+		StorageDriver:       storageDriver,
+		GitCommit:           version.Revision,
+		BuildTime:           version.BuildTime,
+	})
+}
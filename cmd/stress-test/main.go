@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/mazrean/separated-webshell/workspace/docker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	containerNum int
+	sessionNum   int
+	duration     time.Duration
+	userPrefix   string
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "stress-test",
+		Short: "Measure how many concurrent sessions the docker host supports",
+		Long: `stress-test creates workspaces in parallel, connects to them repeatedly from concurrent sessions,
+and prints the latency percentiles of the steps. The workspaces are removed at the end.
+It reads the same environment variables as the server (IMAGE_NAME, CPU_LIMIT, MEMORY_LIMIT, ...).`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if containerNum <= 0 {
+				return fmt.Errorf("invalid container number: %d", containerNum)
+			}
+			if sessionNum <= 0 {
+				return fmt.Errorf("invalid session number: %d", sessionNum)
+			}
+
+			err := docker.SetupClient()
+			if err != nil {
+				return fmt.Errorf("failed to setup docker: %w", err)
+			}
+
+			ws, err := docker.NewWorkspace()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			report := run(ctx, ws, docker.NewWorkspaceConnection())
+
+			return report.print(os.Stdout)
+		},
+	}
+	rootCmd.Flags().IntVarP(&containerNum, "containers", "n", 10, "number of workspaces")
+	rootCmd.Flags().IntVarP(&sessionNum, "sessions", "m", 1, "number of concurrent sessions per workspace")
+	rootCmd.Flags().DurationVarP(&duration, "duration", "t", 30*time.Second, "duration of the connection load")
+	rootCmd.Flags().StringVar(&userPrefix, "user-prefix", "stress", "prefix of the user names of the workspaces")
+
+	err := rootCmd.ExecuteContext(context.Background())
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// run creates and starts the workspaces, connects to them until the duration passes and removes them.
+// Interrupting stops the load and still removes the workspaces.
+func run(ctx context.Context, ws *docker.Workspace, wsc *docker.WorkspaceConnection) *report {
+	r := newReport()
+
+	workspaces := make([]*domain.Workspace, containerNum)
+	var wg sync.WaitGroup
+	for i := 0; i < containerNum; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			workspaces[i] = setupWorkspace(ctx, ws, r, i)
+		}(i)
+	}
+	wg.Wait()
+
+	// the workspaces are removed even if the load is interrupted
+	defer func() {
+		for _, workspace := range workspaces {
+			if workspace == nil {
+				continue
+			}
+
+			err := ws.Remove(context.Background(), workspace)
+			if err != nil {
+				r.addError("remove", err)
+			}
+		}
+	}()
+
+	loadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	loadStartedAt := time.Now()
+	for _, workspace := range workspaces {
+		if workspace == nil {
+			continue
+		}
+
+		for i := 0; i < sessionNum; i++ {
+			wg.Add(1)
+			go func(workspace *domain.Workspace) {
+				defer wg.Done()
+
+				connectLoop(loadCtx, wsc, workspace, r)
+			}(workspace)
+		}
+	}
+	wg.Wait()
+	r.loadDuration = time.Since(loadStartedAt)
+
+	return r
+}
+
+func setupWorkspace(ctx context.Context, ws *docker.Workspace, r *report, i int) *domain.Workspace {
+	userName, err := values.NewUserName(fmt.Sprintf("%s%d", userPrefix, i))
+	if err != nil {
+		r.addError("create", err)
+		return nil
+	}
+
+	// existing workspaces are not touched since they are removed at the end
+	_, err = ws.Get(ctx, userName)
+	if err == nil {
+		r.addError("create", fmt.Errorf("workspace of %s already exists", userName))
+		return nil
+	}
+	if !errors.Is(err, workspace.ErrWorkspaceNotFound) {
+		r.addError("create", err)
+		return nil
+	}
+
+	startedAt := time.Now()
+	workspace, err := ws.Create(ctx, userName)
+	if err != nil {
+		r.addError("create", err)
+		return nil
+	}
+	r.observe("create", time.Since(startedAt))
+
+	startedAt = time.Now()
+	err = ws.Start(ctx, workspace)
+	if err != nil {
+		r.addError("start", err)
+		// the created container is removed in the cleanup
+		return workspace
+	}
+	r.observe("start", time.Since(startedAt))
+
+	return workspace
+}
+
+// connectLoop connects to the workspace and disconnects right away without any input until ctx is done.
+func connectLoop(ctx context.Context, wsc *docker.WorkspaceConnection, workspace *domain.Workspace, r *report) {
+	for ctx.Err() == nil {
+		startedAt := time.Now()
+		connection, err := wsc.Connect(ctx, workspace, "")
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			r.addError("connect", err)
+			continue
+		}
+		r.observe("connect", time.Since(startedAt))
+
+		err = wsc.Disconnect(context.Background(), connection)
+		if err != nil {
+			r.addError("disconnect", err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// steps order of the steps in the report
+var steps = []string{"create", "start", "connect", "disconnect", "remove"}
+
+type report struct {
+	locker       sync.Mutex
+	latencies    map[string][]time.Duration
+	errors       map[string]int
+	lastErrors   map[string]error
+	loadDuration time.Duration
+}
+
+func newReport() *report {
+	return &report{
+		latencies:  map[string][]time.Duration{},
+		errors:     map[string]int{},
+		lastErrors: map[string]error{},
+	}
+}
+
+func (r *report) observe(step string, latency time.Duration) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.latencies[step] = append(r.latencies[step], latency)
+}
+
+func (r *report) addError(step string, err error) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.errors[step]++
+	r.lastErrors[step] = err
+}
+
+func (r *report) print(w io.Writer) error {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "workspaces: %d, sessions per workspace: %d, load duration: %s\n", containerNum, sessionNum, r.loadDuration.Round(time.Millisecond))
+	if r.loadDuration > 0 {
+		fmt.Fprintf(tw, "connect throughput: %.1f/s\n", float64(len(r.latencies["connect"]))/r.loadDuration.Seconds())
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "STEP\tCOUNT\tERRORS\tP50\tP95\tP99\tMAX")
+	for _, step := range steps {
+		latencies := r.latencies[step]
+		if len(latencies) == 0 && r.errors[step] == 0 {
+			continue
+		}
+
+		sort.Slice(latencies, func(i, j int) bool {
+			return latencies[i] < latencies[j]
+		})
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			step,
+			len(latencies),
+			r.errors[step],
+			percentile(latencies, 50),
+			percentile(latencies, 95),
+			percentile(latencies, 99),
+			percentile(latencies, 100),
+		)
+	}
+
+	for _, step := range steps {
+		if err, ok := r.lastErrors[step]; ok {
+			fmt.Fprintf(tw, "\nlast %s error: %s", step, err)
+		}
+	}
+	if len(r.lastErrors) != 0 {
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// percentile nearest-rank percentile of the sorted latencies. "-" if there is no latency.
+func percentile(sorted []time.Duration, p int) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1].Round(time.Microsecond).String()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+type capabilityResult struct {
+	User      string   `json:"user"`
+	Effective []string `json:"effective"`
+	Permitted []string `json:"permitted"`
+	Bounding  []string `json:"bounding"`
+}
+
+func capabilityCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "caps <user>",
+		Short: "Show the capabilities of the workspace of a user",
+		Long: `caps runs capsh inside the running workspace of a user, so the image must contain capsh.
+A warning is logged if a capability in CAPABILITY_BLOCKLIST is effective.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			capabilitySet, err := ws.InspectCapabilities(cmd.Context(), userName)
+			if err != nil {
+				return fmt.Errorf("failed to inspect capabilities: %w", err)
+			}
+
+			result := &capabilityResult{
+				User:      string(userName),
+				Effective: capabilitySet.Effective(),
+				Permitted: capabilitySet.Permitted(),
+				Bounding:  capabilitySet.Bounding(),
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+				fmt.Fprintf(tw, "EFFECTIVE\t%s\n", strings.Join(result.Effective, ","))
+				fmt.Fprintf(tw, "PERMITTED\t%s\n", strings.Join(result.Permitted, ","))
+				fmt.Fprintf(tw, "BOUNDING\t%s\n", strings.Join(result.Bounding, ","))
+
+				return tw.Flush()
+			})
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+func captureCmd() *cobra.Command {
+	var iface string
+
+	cmd := &cobra.Command{
+		Use:   "capture <user> [filter expression]",
+		Short: "Capture packets in the workspace of a user and write pcap to stdout",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			capture, err := ws.StartPacketCapture(ctx, userName, iface, strings.Join(args[1:], " "))
+			if err != nil {
+				return fmt.Errorf("failed to start packet capture: %w", err)
+			}
+			defer capture.Close()
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt)
+			defer signal.Stop(sigCh)
+			go func() {
+				_, ok := <-sigCh
+				if !ok {
+					return
+				}
+
+				err := ws.StopPacketCapture(context.Background(), userName, capture.ID())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "failed to stop packet capture: %+v\n", err)
+				}
+			}()
+
+			_, err = io.Copy(os.Stdout, capture)
+			if err != nil {
+				return fmt.Errorf("failed to copy packets: %w", err)
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&iface, "interface", "i", "eth0", "network interface to capture")
+
+	return cmd
+}
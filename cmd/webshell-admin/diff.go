@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+type diffResult struct {
+	UserA    string   `json:"user_a"`
+	UserB    string   `json:"user_b"`
+	OnlyInA  []string `json:"only_in_a"`
+	OnlyInB  []string `json:"only_in_b"`
+	Modified []string `json:"modified"`
+}
+
+func diffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <user a> <user b>",
+		Short: "Compare the changes from the image between the workspaces of two users",
+		Long: `diff compares the files changed from the image in the workspaces of two users.
+Files changed in both workspaces are shown as modified if the kinds of the changes or the sizes differ.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userA, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			userB, err := values.NewUserName(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			diff, err := ws.CompareContainers(cmd.Context(), userA, userB)
+			if err != nil {
+				return fmt.Errorf("failed to compare workspaces: %w", err)
+			}
+
+			result := &diffResult{
+				UserA:    string(userA),
+				UserB:    string(userB),
+				OnlyInA:  diff.OnlyInA(),
+				OnlyInB:  diff.OnlyInB(),
+				Modified: diff.Modified(),
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				for _, path := range result.OnlyInA {
+					fmt.Fprintf(w, "< %s\n", path)
+				}
+				for _, path := range result.OnlyInB {
+					fmt.Fprintf(w, "> %s\n", path)
+				}
+				for _, path := range result.Modified {
+					fmt.Fprintf(w, "M %s\n", path)
+				}
+
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mazrean/separated-webshell/workspace/docker"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	output string
+
+	ws *docker.Workspace
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "webshell-admin",
+		Short: "Inspect user workspaces from the command line",
+		Long: `webshell-admin inspects the user workspaces of ssh-separator.
+It reads the same environment variables as the server (IMAGE_NAME, NAME_PREFIX, ...).
+
+create and reset go through the REST API of the server,
+since the running server keeps the state of workspaces in memory and would not see a change made directly.
+Workspaces are never started, stopped or removed from here.`,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := checkOutput()
+			if err != nil {
+				return err
+			}
+
+			err = docker.SetupClient()
+			if err != nil {
+				return fmt.Errorf("failed to setup docker: %w", err)
+			}
+
+			ws, err = docker.NewReadOnlyWorkspace()
+			if err != nil {
+				return fmt.Errorf("failed to create workspace: %w", err)
+			}
+
+			return nil
+		},
+	}
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputText, "output format (text|json)")
+
+	rootCmd.AddCommand(
+		listCmd(),
+		statsCmd(),
+		diskUsageCmd(),
+		diffCmd(),
+		pruneCmd(),
+		captureCmd(),
+		capabilityCmd(),
+		networkCmd(),
+		createCmd(),
+		resetCmd(),
+	)
+
+	err := rootCmd.ExecuteContext(context.Background())
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func checkOutput() error {
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format: %s", output)
+	}
+
+	return nil
+}
+
+func printResult(w io.Writer, v interface{}, text func(w io.Writer) error) error {
+	if output == outputJSON {
+		err := json.NewEncoder(w).Encode(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode json: %w", err)
+		}
+
+		return nil
+	}
+
+	return text(w)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+type routeResult struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Device      string `json:"device"`
+	Source      string `json:"source,omitempty"`
+}
+
+type interfaceResult struct {
+	Name       string   `json:"name"`
+	MACAddress string   `json:"mac_address"`
+	MTU        int      `json:"mtu"`
+	State      string   `json:"state"`
+	Addresses  []string `json:"addresses"`
+}
+
+type networkResult struct {
+	User       string             `json:"user"`
+	Routes     []*routeResult     `json:"routes"`
+	Interfaces []*interfaceResult `json:"interfaces"`
+}
+
+func networkCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "net <user>",
+		Short: "Show the routes and the interfaces of the workspace of a user",
+		Long:  `net runs ip inside the running workspace of a user, so the image must contain iproute2(4.13 or later).`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			info, err := ws.NetworkNamespaceInfo(cmd.Context(), userName)
+			if err != nil {
+				return fmt.Errorf("failed to inspect network: %w", err)
+			}
+
+			result := &networkResult{
+				User:       string(userName),
+				Routes:     make([]*routeResult, 0, len(info.Routes())),
+				Interfaces: make([]*interfaceResult, 0, len(info.Interfaces())),
+			}
+			for _, route := range info.Routes() {
+				result.Routes = append(result.Routes, &routeResult{
+					Destination: route.Destination(),
+					Gateway:     route.Gateway(),
+					Device:      route.Device(),
+					Source:      route.Source(),
+				})
+			}
+			for _, iface := range info.Interfaces() {
+				result.Interfaces = append(result.Interfaces, &interfaceResult{
+					Name:       iface.Name(),
+					MACAddress: iface.MACAddress(),
+					MTU:        iface.MTU(),
+					State:      iface.State(),
+					Addresses:  iface.Addresses(),
+				})
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(tw, "INTERFACE\tSTATE\tMTU\tMAC\tADDRESSES")
+				for _, iface := range result.Interfaces {
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", iface.Name, iface.State, iface.MTU, iface.MACAddress, strings.Join(iface.Addresses, ","))
+				}
+				fmt.Fprintln(tw)
+				fmt.Fprintln(tw, "DESTINATION\tGATEWAY\tDEVICE\tSOURCE")
+				for _, route := range result.Routes {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", route.Destination, route.Gateway, route.Device, route.Source)
+				}
+
+				return tw.Flush()
+			})
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+type pruneResult struct {
+	ContainersDeleted int   `json:"containers_deleted"`
+	ImagesDeleted     int   `json:"images_deleted"`
+	VolumesDeleted    int   `json:"volumes_deleted"`
+	SpaceReclaimed    int64 `json:"space_reclaimed"`
+}
+
+func pruneCmd() *cobra.Command {
+	var (
+		isSystem     bool
+		pruneImages  bool
+		pruneVolumes bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old images of IMAGE_NAME not used by any workspace",
+		Long: `prune removes the images of the IMAGE_NAME repository replaced by newer pulls.
+Images used by a workspace are kept. Reset the workspaces on an old image to release it.
+Stopped workspaces and volumes are never pruned since they hold the data of users.
+
+prune --system removes every stopped container labeled app=separated-webshell instead,
+including the stopped workspaces of users, and also the unused images(--images) and volumes(--volumes) with the label.
+Run it only while the server is down; the server creates the workspaces of the users again on start.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isSystem && (pruneImages || pruneVolumes) {
+				return fmt.Errorf("--images and --volumes require --system")
+			}
+
+			var (
+				report *values.PruneReport
+				err    error
+			)
+			if isSystem {
+				report, err = ws.SystemPrune(cmd.Context(), pruneImages, pruneVolumes)
+			} else {
+				report, err = ws.PruneImages(cmd.Context())
+			}
+			if err != nil {
+				return fmt.Errorf("failed to prune: %w", err)
+			}
+
+			result := &pruneResult{
+				ContainersDeleted: report.ContainersDeleted(),
+				ImagesDeleted:     report.ImagesDeleted(),
+				VolumesDeleted:    report.VolumesDeleted(),
+				SpaceReclaimed:    report.SpaceReclaimed(),
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "deleted %d containers, %d images, %d volumes, reclaimed %d bytes\n", result.ContainersDeleted, result.ImagesDeleted, result.VolumesDeleted, result.SpaceReclaimed)
+				return err
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&isSystem, "system", false, "prune stopped containers labeled app=separated-webshell, including stopped workspaces")
+	cmd.Flags().BoolVar(&pruneImages, "images", false, "also prune unused images labeled app=separated-webshell(with --system)")
+	cmd.Flags().BoolVar(&pruneVolumes, "volumes", false, "also prune unused volumes labeled app=separated-webshell(with --system)")
+
+	return cmd
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// apiURL the REST API of the server. Users are managed through the server so that its in-memory state stays consistent.
+var apiURL string
+
+func defaultAPIURL() string {
+	port := os.Getenv("API_PORT")
+	if len(port) == 0 {
+		port = "3000"
+	}
+
+	return "http://localhost:" + port
+}
+
+// callAPI sends body with API_KEY to the REST API of the server.
+func callAPI(ctx context.Context, method string, path string, body map[string]interface{}) error {
+	body["key"] = os.Getenv("API_KEY")
+
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(apiURL, "/")+path, bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call api: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		var resBody struct {
+			Message string `json:"message"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&resBody)
+		if err != nil || len(resBody.Message) == 0 {
+			return fmt.Errorf("api error: %s", res.Status)
+		}
+
+		return fmt.Errorf("api error: %s: %s", res.Status, resBody.Message)
+	}
+
+	return nil
+}
+
+func generatePassword() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < 16; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordChars))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		sb.WriteByte(passwordChars[n.Int64()])
+	}
+
+	return sb.String(), nil
+}
+
+// apiCmd marks cmd as a command of the REST API, which does not need the docker daemon.
+func apiCmd(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return checkOutput()
+	}
+	cmd.Flags().StringVar(&apiURL, "api", defaultAPIURL(), "url of the REST API of the server(API_KEY is used as the key)")
+
+	return cmd
+}
+
+type createResult struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func createCmd() *cobra.Command {
+	var (
+		password string
+		metadata map[string]string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <user>",
+		Short: "Create a user and the workspace through the server",
+		Long: `create adds a user through the REST API of the server.
+A random password is generated and printed if --password is empty.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			if len(password) == 0 {
+				password, err = generatePassword()
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = values.NewPassword(password)
+			if err != nil {
+				return fmt.Errorf("invalid password: %w", err)
+			}
+
+			err = callAPI(cmd.Context(), http.MethodPost, "/new", map[string]interface{}{
+				"name":     string(userName),
+				"cred":     password,
+				"metadata": metadata,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+
+			result := &createResult{
+				User:     string(userName),
+				Password: password,
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "created %s(password: %s)\n", result.User, result.Password)
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringVar(&password, "password", "", "password of the user(8-32 alphanumeric characters)")
+	cmd.Flags().StringToStringVar(&metadata, "metadata", nil, "metadata of the workspace(key=value)")
+
+	return apiCmd(cmd)
+}
+
+type resetResult struct {
+	User string `json:"user"`
+}
+
+func resetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset <user>",
+		Short: "Recreate the workspace of a user through the server",
+		Long: `reset recreates the workspace of a user through the REST API of the server.
+Changes outside the volumes are lost.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			err = callAPI(cmd.Context(), http.MethodPut, "/reset", map[string]interface{}{
+				"name": string(userName),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reset workspace: %w", err)
+			}
+
+			result := &resetResult{
+				User: string(userName),
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "reset %s\n", result.User)
+				return err
+			})
+		},
+	}
+
+	return apiCmd(cmd)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/spf13/cobra"
+)
+
+type workspaceResult struct {
+	User   string `json:"user"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+func newWorkspaceResult(workspace *domain.Workspace) *workspaceResult {
+	return &workspaceResult{
+		User:   string(workspace.UserName()),
+		ID:     string(workspace.ID()),
+		Name:   string(workspace.Name()),
+		Status: workspace.Status.String(),
+	}
+}
+
+func printWorkspaces(workspaces []*domain.Workspace) error {
+	results := make([]*workspaceResult, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		results = append(results, newWorkspaceResult(workspace))
+	}
+
+	return printResult(os.Stdout, results, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "USER\tSTATUS\tID")
+		for _, result := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%.12s\n", result.User, result.Status, result.ID)
+		}
+
+		return tw.Flush()
+	})
+}
+
+func getWorkspace(ctx context.Context, strUserName string) (*domain.Workspace, error) {
+	userName, err := values.NewUserName(strUserName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user name: %w", err)
+	}
+
+	workspace, err := ws.Get(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	return workspace, nil
+}
+
+func listCmd() *cobra.Command {
+	var isOutdated bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all workspaces",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list := ws.List
+			if isOutdated {
+				list = ws.ListOutdated
+			}
+
+			workspaces, err := list(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+
+			return printWorkspaces(workspaces)
+		},
+	}
+
+	cmd.Flags().BoolVar(&isOutdated, "outdated", false, "list only workspaces created with an older container configuration")
+
+	return cmd
+}
+
+type diskUsageResult struct {
+	User          string `json:"user"`
+	WritableBytes int64  `json:"writable_bytes"`
+	RootfsBytes   int64  `json:"rootfs_bytes"`
+}
+
+func diskUsageCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "du <user>",
+		Short: "Show the disk usage of the workspace of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userName, err := values.NewUserName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid user name: %w", err)
+			}
+
+			writableBytes, rootfsBytes, err := ws.DiskUsage(cmd.Context(), userName)
+			if err != nil {
+				return fmt.Errorf("failed to get disk usage: %w", err)
+			}
+
+			result := &diskUsageResult{
+				User:          string(userName),
+				WritableBytes: writableBytes,
+				RootfsBytes:   rootfsBytes,
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(tw, "USER\tWRITABLE\tROOTFS")
+				fmt.Fprintf(tw, "%s\t%d\t%d\n", result.User, result.WritableBytes, result.RootfsBytes)
+
+				return tw.Flush()
+			})
+		},
+	}
+}
+
+type statsResult struct {
+	User        string  `json:"user"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryUsage uint64  `json:"memory_usage"`
+	MemoryLimit uint64  `json:"memory_limit"`
+}
+
+func statsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats <user>",
+		Short: "Show the resource usage of the workspace of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspace, err := getWorkspace(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			stats, err := ws.Stats(cmd.Context(), workspace)
+			if err != nil {
+				return fmt.Errorf("failed to get stats: %w", err)
+			}
+
+			result := &statsResult{
+				User:        string(workspace.UserName()),
+				CPUPercent:  stats.CPUPercent(),
+				MemoryUsage: stats.MemoryUsage(),
+				MemoryLimit: stats.MemoryLimit(),
+			}
+
+			return printResult(os.Stdout, result, func(w io.Writer) error {
+				tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(tw, "USER\tCPU %\tMEM USAGE\tMEM LIMIT")
+				fmt.Fprintf(tw, "%s\t%.2f\t%d\t%d\n", result.User, result.CPUPercent, result.MemoryUsage, result.MemoryLimit)
+
+				return tw.Flush()
+			})
+		},
+	}
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// ConnectTiming durations of the steps to start a session.
+// ContainerStartDuration is 0 if the workspace was already up.
+type ConnectTiming struct {
+	ContainerStartDuration time.Duration
+	ExecCreateDuration     time.Duration
+	ExecAttachDuration     time.Duration
+	FirstByteDuration      time.Duration
+}
@@ -10,6 +10,10 @@ type Connection struct {
 	isTty      bool
 	io         *values.ConnectionIO
 	windowPipe chan *values.Window
+	// initialWindow window size requested on the pty allocation. nil if unknown.
+	initialWindow *values.Window
+	// term TERM requested on the pty allocation. empty if unknown.
+	term string
 }
 
 func NewConnection(isTty bool, io *values.ConnectionIO) *Connection {
@@ -47,3 +51,19 @@ func (c *Connection) WindowSender() chan<- *values.Window {
 func (c *Connection) WindowReceiver() <-chan *values.Window {
 	return c.windowPipe
 }
+
+func (c *Connection) SetInitialWindow(win *values.Window) {
+	c.initialWindow = win
+}
+
+func (c *Connection) InitialWindow() *values.Window {
+	return c.initialWindow
+}
+
+func (c *Connection) SetTerm(term string) {
+	c.term = term
+}
+
+func (c *Connection) Term() string {
+	return c.term
+}
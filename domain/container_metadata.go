@@ -0,0 +1,5 @@
+package domain
+
+// ContainerMetadata annotations of the workspace of a user(e.g. course ID, enrollment date, feature flags).
+// Unlike container labels, they can be changed without recreating the container.
+type ContainerMetadata map[string]string
@@ -0,0 +1,27 @@
+package values
+
+type CapabilitySet struct {
+	effective []string
+	permitted []string
+	bounding  []string
+}
+
+func NewCapabilitySet(effective []string, permitted []string, bounding []string) *CapabilitySet {
+	return &CapabilitySet{
+		effective: effective,
+		permitted: permitted,
+		bounding:  bounding,
+	}
+}
+
+func (cs *CapabilitySet) Effective() []string {
+	return cs.effective
+}
+
+func (cs *CapabilitySet) Permitted() []string {
+	return cs.permitted
+}
+
+func (cs *CapabilitySet) Bounding() []string {
+	return cs.bounding
+}
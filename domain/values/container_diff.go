@@ -0,0 +1,31 @@
+package values
+
+// ContainerDiff differences of the changes from the image between two workspaces.
+type ContainerDiff struct {
+	onlyInA  []string
+	onlyInB  []string
+	modified []string
+}
+
+func NewContainerDiff(onlyInA []string, onlyInB []string, modified []string) *ContainerDiff {
+	return &ContainerDiff{
+		onlyInA:  onlyInA,
+		onlyInB:  onlyInB,
+		modified: modified,
+	}
+}
+
+// OnlyInA paths changed only in the workspace A.
+func (cd *ContainerDiff) OnlyInA() []string {
+	return cd.onlyInA
+}
+
+// OnlyInB paths changed only in the workspace B.
+func (cd *ContainerDiff) OnlyInB() []string {
+	return cd.onlyInB
+}
+
+// Modified paths changed in both workspaces with different results.
+func (cd *ContainerDiff) Modified() []string {
+	return cd.modified
+}
@@ -0,0 +1,96 @@
+package values
+
+type RouteEntry struct {
+	destination string
+	gateway     string
+	device      string
+	source      string
+}
+
+func NewRouteEntry(destination string, gateway string, device string, source string) *RouteEntry {
+	return &RouteEntry{
+		destination: destination,
+		gateway:     gateway,
+		device:      device,
+		source:      source,
+	}
+}
+
+// Destination "default" or the destination network in CIDR notation.
+func (re *RouteEntry) Destination() string {
+	return re.destination
+}
+
+// Gateway empty for routes without a gateway.
+func (re *RouteEntry) Gateway() string {
+	return re.gateway
+}
+
+func (re *RouteEntry) Device() string {
+	return re.device
+}
+
+// Source preferred source address. empty if not set.
+func (re *RouteEntry) Source() string {
+	return re.source
+}
+
+type InterfaceInfo struct {
+	name       string
+	macAddress string
+	mtu        int
+	state      string
+	addresses  []string
+}
+
+func NewInterfaceInfo(name string, macAddress string, mtu int, state string, addresses []string) *InterfaceInfo {
+	return &InterfaceInfo{
+		name:       name,
+		macAddress: macAddress,
+		mtu:        mtu,
+		state:      state,
+		addresses:  addresses,
+	}
+}
+
+func (ii *InterfaceInfo) Name() string {
+	return ii.name
+}
+
+func (ii *InterfaceInfo) MACAddress() string {
+	return ii.macAddress
+}
+
+func (ii *InterfaceInfo) MTU() int {
+	return ii.mtu
+}
+
+// State operational state(UP, DOWN or UNKNOWN).
+func (ii *InterfaceInfo) State() string {
+	return ii.state
+}
+
+// Addresses in CIDR notation.
+func (ii *InterfaceInfo) Addresses() []string {
+	return ii.addresses
+}
+
+type NetworkNSInfo struct {
+	routes     []*RouteEntry
+	interfaces []*InterfaceInfo
+}
+
+func NewNetworkNSInfo(routes []*RouteEntry, interfaces []*InterfaceInfo) *NetworkNSInfo {
+	return &NetworkNSInfo{
+		routes:     routes,
+		interfaces: interfaces,
+	}
+}
+
+func (nni *NetworkNSInfo) Routes() []*RouteEntry {
+	return nni.routes
+}
+
+func (nni *NetworkNSInfo) Interfaces() []*InterfaceInfo {
+	return nni.interfaces
+}
@@ -0,0 +1,34 @@
+package values
+
+type PruneReport struct {
+	containersDeleted int
+	imagesDeleted     int
+	volumesDeleted    int
+	spaceReclaimed    int64
+}
+
+func NewPruneReport(containersDeleted int, imagesDeleted int, volumesDeleted int, spaceReclaimed int64) *PruneReport {
+	return &PruneReport{
+		containersDeleted: containersDeleted,
+		imagesDeleted:     imagesDeleted,
+		volumesDeleted:    volumesDeleted,
+		spaceReclaimed:    spaceReclaimed,
+	}
+}
+
+func (pr *PruneReport) ContainersDeleted() int {
+	return pr.containersDeleted
+}
+
+func (pr *PruneReport) ImagesDeleted() int {
+	return pr.imagesDeleted
+}
+
+func (pr *PruneReport) VolumesDeleted() int {
+	return pr.volumesDeleted
+}
+
+// SpaceReclaimed bytes freed by the prune.
+func (pr *PruneReport) SpaceReclaimed() int64 {
+	return pr.spaceReclaimed
+}
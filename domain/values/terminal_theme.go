@@ -0,0 +1,70 @@
+package values
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const terminalPaletteSize = 16
+
+var colorExpression = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|rgb:[0-9a-fA-F]{2}/[0-9a-fA-F]{2}/[0-9a-fA-F]{2})$`)
+
+// TerminalTheme ANSI color theme of a terminal. Empty colors are left unchanged.
+type TerminalTheme struct {
+	background   string
+	foreground   string
+	colorPalette [terminalPaletteSize]string
+}
+
+func NewTerminalTheme(background string, foreground string, colorPalette []string) (*TerminalTheme, error) {
+	if len(colorPalette) > terminalPaletteSize {
+		return nil, fmt.Errorf("too many palette colors: %d", len(colorPalette))
+	}
+
+	theme := &TerminalTheme{
+		background: background,
+		foreground: foreground,
+	}
+	copy(theme.colorPalette[:], colorPalette)
+
+	colors := append([]string{background, foreground}, colorPalette...)
+	for _, color := range colors {
+		if color != "" && !colorExpression.MatchString(color) {
+			return nil, errors.New("invalid color")
+		}
+	}
+
+	return theme, nil
+}
+
+func (tt *TerminalTheme) Background() string {
+	return tt.background
+}
+
+func (tt *TerminalTheme) Foreground() string {
+	return tt.foreground
+}
+
+func (tt *TerminalTheme) ColorPalette() [terminalPaletteSize]string {
+	return tt.colorPalette
+}
+
+// EscapeSequence OSC 4(color palette) and OSC 10/11(foreground/background) sequences applying the theme
+func (tt *TerminalTheme) EscapeSequence() string {
+	sb := strings.Builder{}
+	for i, color := range tt.colorPalette {
+		if color != "" {
+			fmt.Fprintf(&sb, "\x1b]4;%d;%s\x07", i, color)
+		}
+	}
+	if tt.foreground != "" {
+		fmt.Fprintf(&sb, "\x1b]10;%s\x07", tt.foreground)
+	}
+	if tt.background != "" {
+		fmt.Fprintf(&sb, "\x1b]11;%s\x07", tt.background)
+	}
+
+	return sb.String()
+}
@@ -1,5 +1,7 @@
 package values
 
+import "fmt"
+
 type (
 	WorkspaceID     string
 	WorkspaceName   string
@@ -11,8 +13,23 @@ const (
 	StatusDown WorkspaceStatus = iota
 	// StatusUp the status of a workspace when it is up
 	StatusUp WorkspaceStatus = iota
+	// StatusRemoved the status of a workspace after its container is removed
+	StatusRemoved WorkspaceStatus = iota
 )
 
+func (ws WorkspaceStatus) String() string {
+	switch ws {
+	case StatusDown:
+		return "down"
+	case StatusUp:
+		return "up"
+	case StatusRemoved:
+		return "removed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(ws))
+	}
+}
+
 func NewWorkspaceID(id string) WorkspaceID {
 	return WorkspaceID(id)
 }
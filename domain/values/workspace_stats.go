@@ -0,0 +1,27 @@
+package values
+
+type WorkspaceStats struct {
+	cpuPercent  float64
+	memoryUsage uint64
+	memoryLimit uint64
+}
+
+func NewWorkspaceStats(cpuPercent float64, memoryUsage uint64, memoryLimit uint64) *WorkspaceStats {
+	return &WorkspaceStats{
+		cpuPercent:  cpuPercent,
+		memoryUsage: memoryUsage,
+		memoryLimit: memoryLimit,
+	}
+}
+
+func (ws *WorkspaceStats) CPUPercent() float64 {
+	return ws.cpuPercent
+}
+
+func (ws *WorkspaceStats) MemoryUsage() uint64 {
+	return ws.memoryUsage
+}
+
+func (ws *WorkspaceStats) MemoryLimit() uint64 {
+	return ws.memoryLimit
+}
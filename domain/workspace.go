@@ -13,6 +13,8 @@ type Workspace struct {
 	userName      values.UserName
 	Status        values.WorkspaceStatus
 	connectionNum int32
+	// stopping 1 while the server stops the workspace on purpose
+	stopping int32
 }
 
 func NewWorkspace(id values.WorkspaceID, name values.WorkspaceName, userName values.UserName) *Workspace {
@@ -56,3 +58,18 @@ func (w *Workspace) RemoveConnection() error {
 
 	return nil
 }
+
+// SetStopping marks the workspace as being stopped by the server, so that its exit is not taken for a crash.
+func (w *Workspace) SetStopping(stopping bool) {
+	var v int32
+	if stopping {
+		v = 1
+	}
+
+	atomic.StoreInt32(&w.stopping, v)
+}
+
+// IsStopping returns whether the server is stopping the workspace on purpose.
+func (w *Workspace) IsStopping() bool {
+	return atomic.LoadInt32(&w.stopping) == 1
+}
@@ -2,19 +2,24 @@ package domain
 
 import (
 	"io"
+	"time"
 
 	"github.com/mazrean/separated-webshell/domain/values"
 )
 
 type WorkspaceConnection struct {
-	id values.WorkspaceConnectionID
-	io *values.WorkspaceIO
+	id     values.WorkspaceConnectionID
+	io     *values.WorkspaceIO
+	Timing ConnectTiming
+	// createdAt when the connection was established
+	createdAt time.Time
 }
 
 func NewWorkspaceConnection(id values.WorkspaceConnectionID, io *values.WorkspaceIO) *WorkspaceConnection {
 	return &WorkspaceConnection{
-		id: id,
-		io: io,
+		id:        id,
+		io:        io,
+		createdAt: time.Now(),
 	}
 }
 
@@ -22,6 +27,10 @@ func (wc *WorkspaceConnection) ID() values.WorkspaceConnectionID {
 	return wc.id
 }
 
+func (wc *WorkspaceConnection) CreatedAt() time.Time {
+	return wc.createdAt
+}
+
 func (wc *WorkspaceConnection) WriteCloser() io.WriteCloser {
 	return wc.io.WriteCloser()
 }
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// workspaceTransitions statuses a workspace can change to from each status.
+// A removed workspace does not change anymore.
+var workspaceTransitions = map[values.WorkspaceStatus][]values.WorkspaceStatus{
+	values.StatusDown: {values.StatusUp, values.StatusRemoved},
+	values.StatusUp:   {values.StatusDown, values.StatusRemoved},
+}
+
+// ErrInvalidTransition the status of a workspace can not change from From to To(e.g. stopping a stopped workspace).
+type ErrInvalidTransition struct {
+	From values.WorkspaceStatus
+	To   values.WorkspaceStatus
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("invalid workspace status transition from %s to %s", e.From, e.To)
+}
+
+// ValidateTransition returns *ErrInvalidTransition if the status can not change from from to to.
+func ValidateTransition(from values.WorkspaceStatus, to values.WorkspaceStatus) error {
+	for _, status := range workspaceTransitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &ErrInvalidTransition{
+		From: from,
+		To:   to,
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -41,6 +42,8 @@ func main() {
 	api := server.API
 	ssh := server.SSH
 
+	server.Watchdog.Start(context.Background())
+
 	go func() {
 		panic(api.Start(apiPort))
 	}()
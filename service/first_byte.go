@@ -0,0 +1,74 @@
+package service
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var strSlowStartThreshold = os.Getenv("SLOW_START_THRESHOLD")
+
+var (
+	firstByteHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Help:      "Time from starting the session to the first byte from the workspace.",
+		Namespace: "webshell",
+		Name:      "exec_first_byte_seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+	connectStepHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Help:      "Time of each step to start the session.",
+		Namespace: "webshell",
+		Name:      "connect_step_seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"step"})
+	slowStartCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Help:      "Number of sessions slower than the slow start threshold to get the first byte.",
+		Namespace: "webshell",
+		Name:      "slow_start_total",
+	})
+)
+
+// firstByteReader calls onFirstByte once when the first byte is read.
+type firstByteReader struct {
+	io.Reader
+	once        sync.Once
+	onFirstByte func()
+}
+
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.once.Do(r.onFirstByte)
+	}
+
+	return n, err
+}
+
+// observeConnectTiming records the timing of the session start and alerts if the time to the first byte exceeds slowStartThreshold.
+// The container start is not recorded for sessions attached to a running workspace.
+func (p *Pipe) observeConnectTiming(workspace *domain.Workspace, timing domain.ConnectTiming) {
+	firstByteHistogram.Observe(timing.FirstByteDuration.Seconds())
+
+	if timing.ContainerStartDuration > 0 {
+		connectStepHistogram.WithLabelValues("container_start").Observe(timing.ContainerStartDuration.Seconds())
+	}
+	connectStepHistogram.WithLabelValues("exec_create").Observe(timing.ExecCreateDuration.Seconds())
+	connectStepHistogram.WithLabelValues("exec_attach").Observe(timing.ExecAttachDuration.Seconds())
+
+	if p.slowStartThreshold > 0 && timing.FirstByteDuration > p.slowStartThreshold {
+		slowStartCounter.Inc()
+		log.Printf(
+			"slow start of %s: first byte in %s(container start: %s, exec create: %s, exec attach: %s)\n",
+			workspace.Name(),
+			timing.FirstByteDuration,
+			timing.ContainerStartDuration,
+			timing.ExecCreateDuration,
+			timing.ExecAttachDuration,
+		)
+	}
+}
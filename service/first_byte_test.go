@@ -0,0 +1,27 @@
+package service
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstByteReader(t *testing.T) {
+	t.Parallel()
+
+	callNum := 0
+	reader := &firstByteReader{
+		Reader: iotest.OneByteReader(strings.NewReader("output")),
+		onFirstByte: func() {
+			callNum++
+		},
+	}
+
+	output, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "output", string(output))
+	assert.Equal(t, 1, callNum)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrMaintenance the server is under maintenance
+var ErrMaintenance = errors.New("maintenance")
+
+const defaultMaintenanceMessage = "The server is under maintenance. Please try again later."
+
+type maintenanceState struct {
+	on      bool
+	message string
+}
+
+// Maintenance global maintenance toggle.
+// New sessions and users are rejected while it is on. Existing sessions are not affected.
+type Maintenance struct {
+	state atomic.Value
+}
+
+func NewMaintenance() *Maintenance {
+	m := &Maintenance{}
+	m.state.Store(&maintenanceState{})
+
+	return m
+}
+
+// SetMaintenance turns the maintenance mode on or off.
+// The default message is used if message is empty.
+func (m *Maintenance) SetMaintenance(on bool, message string) {
+	if len(message) == 0 {
+		message = defaultMaintenanceMessage
+	}
+
+	m.state.Store(&maintenanceState{
+		on:      on,
+		message: message,
+	})
+}
+
+// Maintenance returns whether the maintenance mode is on and the message for users.
+func (m *Maintenance) Maintenance() (bool, string) {
+	state := m.state.Load().(*maintenanceState)
+
+	return state.on, state.message
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	strMemoryAlertThreshold = os.Getenv("MEMORY_ALERT_THRESHOLD")
+	strMemoryStopThreshold  = os.Getenv("MEMORY_STOP_THRESHOLD")
+)
+
+const (
+	defaultMemoryMonitorInterval = 5 * time.Second
+
+	alertLabel = "alert"
+	stopLabel  = "stop"
+
+	memoryAlertMessage = "\r\n\x1b[1;33mWarning: memory usage is %.0f%% of the limit. Your session will be terminated if it keeps growing.\x1b[0m\r\n"
+	memoryStopMessage  = "\r\n\x1b[1;31mThe workspace was stopped: memory usage is %.0f%% of the limit.\x1b[0m\r\n"
+)
+
+var memoryAlertCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Help:      "Number of high memory usage alerts.",
+	Namespace: "webshell",
+	Name:      "memory_alert_total",
+}, []string{"level"})
+
+func parseMemoryThreshold(strThreshold string) (float64, error) {
+	if len(strThreshold) == 0 {
+		return 0, nil
+	}
+
+	threshold, err := strconv.ParseFloat(strThreshold, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse float: %w", err)
+	}
+	if threshold <= 0 || threshold > 1 {
+		return 0, fmt.Errorf("threshold must be in (0, 1]: %g", threshold)
+	}
+
+	return threshold, nil
+}
+
+// memoryMonitor runs one monitor per workspace while the workspace has connections.
+type memoryMonitor struct {
+	ww             workspace.IWorkspace
+	alertThreshold float64
+	stopThreshold  float64
+	interval       time.Duration
+
+	locker     sync.Mutex
+	workspaces map[values.WorkspaceName]*monitoredWorkspace
+}
+
+type monitoredWorkspace struct {
+	cancel      context.CancelFunc
+	connections map[*domain.Connection]struct{}
+}
+
+func newMemoryMonitor(ww workspace.IWorkspace, alertThreshold float64, stopThreshold float64) *memoryMonitor {
+	return &memoryMonitor{
+		ww:             ww,
+		alertThreshold: alertThreshold,
+		stopThreshold:  stopThreshold,
+		interval:       defaultMemoryMonitorInterval,
+		workspaces:     map[values.WorkspaceName]*monitoredWorkspace{},
+	}
+}
+
+// Add registers the connection and starts monitoring the workspace on its first connection.
+func (mm *memoryMonitor) Add(ws *domain.Workspace, connection *domain.Connection) {
+	mm.locker.Lock()
+	defer mm.locker.Unlock()
+
+	monitored, ok := mm.workspaces[ws.Name()]
+	if ok {
+		monitored.connections[connection] = struct{}{}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mm.workspaces[ws.Name()] = &monitoredWorkspace{
+		cancel: cancel,
+		connections: map[*domain.Connection]struct{}{
+			connection: {},
+		},
+	}
+
+	safeGo(ctx, "memory_monitor", func(ctx context.Context) {
+		mm.run(ctx, ws)
+	})
+}
+
+// Remove unregisters the connection and stops monitoring the workspace on its last connection.
+func (mm *memoryMonitor) Remove(ws *domain.Workspace, connection *domain.Connection) {
+	mm.locker.Lock()
+	defer mm.locker.Unlock()
+
+	monitored, ok := mm.workspaces[ws.Name()]
+	if !ok {
+		return
+	}
+
+	delete(monitored.connections, connection)
+	if len(monitored.connections) == 0 {
+		monitored.cancel()
+		delete(mm.workspaces, ws.Name())
+	}
+}
+
+// run polls the memory usage of the workspace until ctx is done.
+// It warns the clients when the usage exceeds alertThreshold,
+// and stops the workspace when the usage exceeds stopThreshold so that every session of the workspace ends.
+func (mm *memoryMonitor) run(ctx context.Context, ws *domain.Workspace) {
+	ticker := time.NewTicker(mm.interval)
+	defer ticker.Stop()
+
+	isAlerted := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := mm.ww.Stats(ctx, ws)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("failed to get workspace stats: %+v\n", err)
+			}
+			continue
+		}
+		if stats.MemoryLimit() == 0 {
+			continue
+		}
+
+		usage := float64(stats.MemoryUsage()) / float64(stats.MemoryLimit())
+
+		if mm.stopThreshold > 0 && usage >= mm.stopThreshold {
+			memoryAlertCounter.WithLabelValues(stopLabel).Inc()
+			log.Printf("stop workspace %s: memory usage %.0f%%\n", ws.Name(), usage*100)
+
+			mm.notify(ws, memoryStopMessage, usage)
+
+			err := mm.ww.Stop(ctx, ws)
+			var transitionErr *domain.ErrInvalidTransition
+			if err != nil && !errors.As(err, &transitionErr) {
+				log.Printf("failed to stop workspace: %+v\n", err)
+			}
+
+			// the monitor is kept until the last session ends, since the workspace may be started again by a new session
+			isAlerted = false
+			continue
+		}
+
+		if mm.alertThreshold <= 0 || usage < mm.alertThreshold {
+			isAlerted = false
+			continue
+		}
+
+		if !isAlerted {
+			memoryAlertCounter.WithLabelValues(alertLabel).Inc()
+
+			mm.notify(ws, memoryAlertMessage, usage)
+
+			isAlerted = true
+		}
+	}
+}
+
+// notify writes the message to every tty connection of the workspace.
+func (mm *memoryMonitor) notify(ws *domain.Workspace, format string, usage float64) {
+	mm.locker.Lock()
+	monitored, ok := mm.workspaces[ws.Name()]
+	connections := make([]*domain.Connection, 0)
+	if ok {
+		for connection := range monitored.connections {
+			connections = append(connections, connection)
+		}
+	}
+	mm.locker.Unlock()
+
+	for _, connection := range connections {
+		if !connection.IsTty() {
+			continue
+		}
+
+		_, err := fmt.Fprintf(connection.Stdout(), format, usage*100)
+		if err != nil {
+			log.Printf("failed to write memory message: %+v\n", err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace/mock_workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMonitor(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+	workspace := domain.NewWorkspace("container_id", "user-test", "test")
+
+	mm := newMemoryMonitor(mockWorkspace, 0.9, 0.95)
+	mm.interval = time.Millisecond
+
+	ttyStdout := &bytes.Buffer{}
+	ttyConnection := domain.NewConnection(true, values.NewConnectionIO(strings.NewReader(""), ttyStdout, ttyStdout, func() error {
+		return nil
+	}))
+	stdout := &bytes.Buffer{}
+	connection := domain.NewConnection(false, values.NewConnectionIO(strings.NewReader(""), stdout, stdout, func() error {
+		return nil
+	}))
+
+	usages := []uint64{50, 92, 93, 80, 91, 96}
+	calls := make([]*gomock.Call, 0, len(usages)+1)
+	for _, usage := range usages {
+		calls = append(calls, mockWorkspace.
+			EXPECT().
+			Stats(gomock.Any(), workspace).
+			Return(values.NewWorkspaceStats(0, usage, 100), nil))
+	}
+	// the workspace is stopped and polled until the sessions end
+	calls = append(calls, mockWorkspace.
+		EXPECT().
+		Stats(gomock.Any(), workspace).
+		Return(values.NewWorkspaceStats(0, 0, 0), nil).
+		AnyTimes())
+	gomock.InOrder(calls...)
+
+	stopped := make(chan struct{})
+	mockWorkspace.
+		EXPECT().
+		Stop(gomock.Any(), workspace).
+		DoAndReturn(func(ctx context.Context, ws *domain.Workspace) error {
+			close(stopped)
+			return nil
+		})
+
+	mm.Add(workspace, ttyConnection)
+	mm.Add(workspace, connection)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("workspace is not stopped")
+	}
+
+	mm.Remove(workspace, ttyConnection)
+	mm.Remove(workspace, connection)
+
+	expected := fmt.Sprintf(memoryAlertMessage, 92.0) +
+		fmt.Sprintf(memoryAlertMessage, 91.0) +
+		fmt.Sprintf(memoryStopMessage, 96.0)
+	assert.Equal(t, expected, ttyStdout.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestMemoryMonitorLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+	mm := newMemoryMonitor(mockWorkspace, 0.9, 0.95)
+	mm.interval = time.Hour
+
+	workspace := domain.NewWorkspace("container_id", "user-test", "test")
+	connection1 := domain.NewConnection(true, values.NewConnectionIO(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}, nil))
+	connection2 := domain.NewConnection(true, values.NewConnectionIO(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}, nil))
+
+	mm.Add(workspace, connection1)
+	monitored := mm.workspaces[workspace.Name()]
+	assert.NotNil(t, monitored)
+
+	// the second connection shares the monitor of the first one
+	mm.Add(workspace, connection2)
+	assert.Len(t, mm.workspaces, 1)
+	assert.Same(t, monitored, mm.workspaces[workspace.Name()])
+
+	mm.Remove(workspace, connection1)
+	assert.Len(t, mm.workspaces, 1)
+
+	mm.Remove(workspace, connection2)
+	assert.Empty(t, mm.workspaces)
+}
+
+func TestParseMemoryThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		str         string
+		expected    float64
+		isErr       bool
+	}{
+		{
+			description: "empty",
+			str:         "",
+			expected:    0,
+		},
+		{
+			description: "valid",
+			str:         "0.9",
+			expected:    0.9,
+		},
+		{
+			description: "greater than 1",
+			str:         "90",
+			isErr:       true,
+		},
+		{
+			description: "not a number",
+			str:         "high",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			threshold, err := parseMemoryThreshold(test.str)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, threshold)
+		})
+	}
+}
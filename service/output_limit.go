@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrOutputLimitExceeded the output of the session exceeded the limit
+	ErrOutputLimitExceeded = errors.New("output limit exceeded")
+)
+
+const outputLimitExceededMessage = "\r\nYour session was terminated: output limit exceeded\r\n"
+
+// outputLimiter limits the total bytes written to the client in a session.
+// Writers created from the same outputLimiter share the limit.
+type outputLimiter struct {
+	remaining int64
+}
+
+func newOutputLimiter(limit int64) *outputLimiter {
+	return &outputLimiter{
+		remaining: limit,
+	}
+}
+
+func (ol *outputLimiter) Writer(w io.Writer) io.Writer {
+	return &limitedWriter{
+		limiter: ol,
+		writer:  w,
+	}
+}
+
+type limitedWriter struct {
+	limiter *outputLimiter
+	writer  io.Writer
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= lw.limiter.remaining {
+		n, err := lw.writer.Write(p)
+		lw.limiter.remaining -= int64(n)
+
+		return n, err
+	}
+
+	n, err := lw.writer.Write(p[:lw.limiter.remaining])
+	lw.limiter.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	return n, ErrOutputLimitExceeded
+}
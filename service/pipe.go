@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/mazrean/separated-webshell/domain"
@@ -16,36 +19,200 @@ import (
 )
 
 var (
-	welcome = os.Getenv("WELCOME")
+	welcome           = os.Getenv("WELCOME")
+	strOutputLimit    = os.Getenv("OUTPUT_LIMIT")
+	themeBackground   = os.Getenv("THEME_BACKGROUND")
+	themeForeground   = os.Getenv("THEME_FOREGROUND")
+	themePalette      = os.Getenv("THEME_PALETTE")
+	strConnectTimeout = os.Getenv("CONNECT_TIMEOUT")
 )
 
+var (
+	// ErrOOMKilled the session was terminated by the OOM killer
+	ErrOOMKilled = errors.New("oom killed")
+	// ErrWorkspaceUnrecoverable the workspace cannot be started even after recreation
+	ErrWorkspaceUnrecoverable = errors.New("workspace unrecoverable")
+	// ErrConnectTimeout the workspace was not ready for the session within the connect timeout
+	ErrConnectTimeout = errors.New("connect timeout")
+)
+
+const oomKilledMessage = "\r\nYour session was terminated: out of memory\r\n"
+
 type IPipe interface {
 	Pipe(ctx context.Context, userName values.UserName, connection *domain.Connection) error
 }
 
 type Pipe struct {
-	sw  store.IWorkspace
-	wwc workspace.IWorkspaceConnection
-	ww  workspace.IWorkspace
+	sw          store.IWorkspace
+	wwc         workspace.IWorkspaceConnection
+	ww          workspace.IWorkspace
+	maintenance *Maintenance
+	outputLimit int64
+	theme       *values.TerminalTheme
+	// connectTimeout bounds starting and attaching to the workspace. 0 means no timeout.
+	connectTimeout time.Duration
+	resizeDebounce time.Duration
+	// slowStartThreshold alerts sessions slower than this to get the first byte. 0 disables the alert.
+	slowStartThreshold time.Duration
+	// quota limits the shell time of each user per day. nil means no limit.
+	quota *quotaTracker
+	// memoryMonitor watches the memory usage of workspaces with connections. nil means no monitoring.
+	memoryMonitor *memoryMonitor
+	// hooks the callbacks on the start and the end of sessions. nil means no callbacks.
+	hooks *SessionHooks
 }
 
-func NewPipe(sw store.IWorkspace, wwc workspace.IWorkspaceConnection, ww workspace.IWorkspace) *Pipe {
-	return &Pipe{
-		sw:  sw,
-		wwc: wwc,
-		ww:  ww,
+func NewPipe(sw store.IWorkspace, wwc workspace.IWorkspaceConnection, ww workspace.IWorkspace, maintenance *Maintenance) (*Pipe, error) {
+	var outputLimit int64
+	if len(strOutputLimit) != 0 {
+		var err error
+		outputLimit, err = strconv.ParseInt(strOutputLimit, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output limit: %w", err)
+		}
+		if outputLimit < 0 {
+			return nil, fmt.Errorf("invalid output limit: %d", outputLimit)
+		}
+	}
+
+	var theme *values.TerminalTheme
+	if len(themeBackground) != 0 || len(themeForeground) != 0 || len(themePalette) != 0 {
+		var palette []string
+		if len(themePalette) != 0 {
+			palette = strings.Split(themePalette, ",")
+		}
+
+		var err error
+		theme, err = values.NewTerminalTheme(themeBackground, themeForeground, palette)
+		if err != nil {
+			return nil, fmt.Errorf("invalid terminal theme: %w", err)
+		}
+	}
+
+	memoryAlertThreshold, err := parseMemoryThreshold(strMemoryAlertThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory alert threshold: %w", err)
+	}
+
+	memoryStopThreshold, err := parseMemoryThreshold(strMemoryStopThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory stop threshold: %w", err)
+	}
+
+	var connectTimeout time.Duration
+	if len(strConnectTimeout) != 0 {
+		connectTimeout, err = time.ParseDuration(strConnectTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connect timeout: %w", err)
+		}
+		if connectTimeout < 0 {
+			return nil, fmt.Errorf("invalid connect timeout: %s", connectTimeout)
+		}
+	}
+
+	resizeDebounce := defaultResizeDebounce
+	if len(strResizeDebounce) != 0 {
+		resizeDebounce, err = time.ParseDuration(strResizeDebounce)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resize debounce: %w", err)
+		}
 	}
+
+	var slowStartThreshold time.Duration
+	if len(strSlowStartThreshold) != 0 {
+		slowStartThreshold, err = time.ParseDuration(strSlowStartThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slow start threshold: %w", err)
+		}
+	}
+
+	var memoryMonitor *memoryMonitor
+	if memoryAlertThreshold > 0 || memoryStopThreshold > 0 {
+		memoryMonitor = newMemoryMonitor(ww, memoryAlertThreshold, memoryStopThreshold)
+	}
+
+	var quota *quotaTracker
+	if len(strDailyShellQuota) != 0 {
+		dailyShellQuota, err := time.ParseDuration(strDailyShellQuota)
+		if err != nil {
+			return nil, fmt.Errorf("invalid daily shell quota: %w", err)
+		}
+		if dailyShellQuota <= 0 {
+			return nil, fmt.Errorf("invalid daily shell quota: %s", dailyShellQuota)
+		}
+
+		quota = newQuotaTracker(dailyShellQuota)
+	}
+
+	return &Pipe{
+		sw:                 sw,
+		wwc:                wwc,
+		ww:                 ww,
+		maintenance:        maintenance,
+		outputLimit:        outputLimit,
+		theme:              theme,
+		connectTimeout:     connectTimeout,
+		resizeDebounce:     resizeDebounce,
+		slowStartThreshold: slowStartThreshold,
+		quota:              quota,
+		memoryMonitor:      memoryMonitor,
+		hooks:              sessionHooks,
+	}, nil
 }
 
-func (p *Pipe) Pipe(ctx context.Context, userName values.UserName, connection *domain.Connection) error {
+func (p *Pipe) Pipe(ctx context.Context, userName values.UserName, connection *domain.Connection) (err error) {
+	if p.maintenance != nil {
+		isMaintenance, message := p.maintenance.Maintenance()
+		if isMaintenance {
+			if connection.IsTty() {
+				_, err := io.WriteString(connection.Stdout(), "\r\n"+message+"\r\n")
+				if err != nil {
+					log.Printf("failed to write maintenance message: %+v\n", err)
+				}
+			}
+
+			return ErrMaintenance
+		}
+	}
+
+	var quotaRemaining time.Duration
+	if p.quota != nil {
+		quotaRemaining = p.quota.Remaining(userName)
+		if quotaRemaining <= 0 {
+			if connection.IsTty() {
+				_, err := io.WriteString(connection.Stdout(), quotaExhaustedMessage)
+				if err != nil {
+					log.Printf("failed to write quota exhausted message: %+v\n", err)
+				}
+			}
+
+			return ErrQuotaExhausted
+		}
+	}
+
 	workspace, err := p.sw.Get(ctx, userName)
 	if err != nil {
 		return fmt.Errorf("failed to get workspace: %w", err)
 	}
 
+	startedAt := time.Now()
+
+	setupCtx := ctx
+	if p.connectTimeout > 0 {
+		var cancel context.CancelFunc
+		setupCtx, cancel = context.WithTimeout(ctx, p.connectTimeout)
+		defer cancel()
+	}
+
+	var containerStartDuration time.Duration
 	if workspace.Status == values.StatusDown {
-		err = p.ww.Start(ctx, workspace)
+		containerStartedAt := time.Now()
+		workspace, err = p.startWorkspace(setupCtx, userName, workspace)
+		containerStartDuration = time.Since(containerStartedAt)
 		if err != nil {
+			if isConnectTimeout(ctx, setupCtx) {
+				return fmt.Errorf("%w: failed to start workspace: %v", ErrConnectTimeout, err)
+			}
 			return fmt.Errorf("failed to start workspace: %w", err)
 		}
 	}
@@ -55,67 +222,243 @@ func (p *Pipe) Pipe(ctx context.Context, userName values.UserName, connection *d
 		return fmt.Errorf("failed to add connection: %w", err)
 	}
 
-	workspaceConnection, err := p.wwc.Connect(ctx, workspace)
+	workspaceConnection, err := p.wwc.Connect(setupCtx, workspace, connection.Term())
 	if err != nil {
+		p.removeConnection(workspace)
+
+		if isConnectTimeout(ctx, setupCtx) {
+			return fmt.Errorf("%w: connect to workspace error: %v", ErrConnectTimeout, err)
+		}
 		return fmt.Errorf("connect to workspace error: %w", err)
 	}
+	workspaceConnection.Timing.ContainerStartDuration = containerStartDuration
 	defer func() {
-		ctx := context.Background()
-
-		err := p.wwc.Disconnect(ctx, workspaceConnection)
+		err := p.wwc.Disconnect(context.Background(), workspaceConnection)
 		if err != nil {
 			log.Printf("failed to disconnect: %+v", err)
 			return
 		}
 
-		err = workspace.RemoveConnection()
-		if err != nil {
-			log.Printf("connection num missmatch: %+v", err)
-		}
+		p.removeConnection(workspace)
+	}()
 
-		if workspace.ConnectionNum() == 0 {
-			err = p.ww.Stop(ctx, workspace)
+	if p.hooks != nil {
+		sessionID := string(workspaceConnection.ID())
+
+		if p.hooks.OnSessionStart != nil {
+			err = p.hooks.OnSessionStart(ctx, userName, sessionID)
 			if err != nil {
-				log.Printf("failed to stop workspace: %+v", err)
+				if p.hooks.IsStartErrorFatal {
+					return fmt.Errorf("failed in session start hook: %w", err)
+				}
+				log.Printf("failed in session start hook: %+v\n", err)
 			}
 		}
-	}()
 
-	go func() {
-		for win := range connection.WindowReceiver() {
-			err := p.wwc.Resize(ctx, workspaceConnection, win)
-			if err != nil {
-				log.Printf("failed to resize window: %+v", err)
-			}
+		if p.hooks.OnSessionEnd != nil {
+			sessionStartedAt := time.Now()
+			defer func() {
+				summary := &SessionSummary{
+					StartedAt: sessionStartedAt,
+					Duration:  time.Since(sessionStartedAt),
+					Err:       err,
+				}
+
+				// the session context is usually done when the session ends
+				hookErr := p.hooks.OnSessionEnd(context.Background(), userName, sessionID, summary)
+				if hookErr != nil {
+					log.Printf("failed in session end hook: %+v\n", hookErr)
+				}
+			}()
 		}
-	}()
+	}
+
+	if p.quota != nil {
+		sessionStartedAt := time.Now()
+		defer func() {
+			p.quota.Record(userName, time.Since(sessionStartedAt))
+		}()
+
+		limitCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		safeGo(limitCtx, "quota", func(ctx context.Context) {
+			p.limitSession(ctx, quotaRemaining, connection)
+		})
+	}
+
+	if p.memoryMonitor != nil {
+		p.memoryMonitor.Add(workspace, connection)
+		defer p.memoryMonitor.Remove(workspace, connection)
+	}
+
+	// the initial size is applied before any output so that the first screen of every session is rendered in the right size
+	initialWindow := connection.InitialWindow()
+	if connection.IsTty() && initialWindow != nil {
+		err := p.wwc.Resize(ctx, workspaceConnection, initialWindow)
+		if err != nil {
+			log.Printf("failed to apply initial window: %+v", err)
+			initialWindow = nil
+		}
+	}
+
+	safeGo(ctx, "resize", func(ctx context.Context) {
+		p.resizeWorkspace(ctx, workspaceConnection, initialWindow, connection.WindowReceiver())
+	})
 
+	outputErrCh := make(chan error, 1)
 	go func() {
 		defer connection.Close()
-		if connection.IsTty() {
-			if len(welcome) != 0 {
-				_, err := io.Copy(connection.Stdout(), strings.NewReader(welcome))
+		defer close(outputErrCh)
+
+		// not restarted on panic since the output of the session cannot be resumed
+		runRecovered(ctx, "output", func(ctx context.Context) {
+			var output io.Reader = &firstByteReader{
+				Reader: workspaceConnection.ReadCloser(),
+				onFirstByte: func() {
+					workspaceConnection.Timing.FirstByteDuration = time.Since(startedAt)
+					p.observeConnectTiming(workspace, workspaceConnection.Timing)
+				},
+			}
+
+			stdout, stderr := connection.Stdout(), connection.Stderr()
+			if p.outputLimit > 0 {
+				limiter := newOutputLimiter(p.outputLimit)
+				stdout, stderr = limiter.Writer(stdout), limiter.Writer(stderr)
+			}
+
+			var err error
+			if connection.IsTty() {
+				if p.theme != nil {
+					_, err := io.WriteString(connection.Stdout(), p.theme.EscapeSequence())
+					if err != nil {
+						log.Printf("failed to write terminal theme: %+v", err)
+					}
+				}
+
+				if len(welcome) != 0 {
+					_, err := io.Copy(connection.Stdout(), strings.NewReader(welcome))
+					if err != nil {
+						log.Printf("failed to copy fonts: %+v", err)
+					}
+				}
+
+				_, err = io.Copy(stdout, output)
+				if err != nil && !errors.Is(err, ErrOutputLimitExceeded) {
+					log.Printf("failed to copy stdin: %+v\n", err)
+				}
+			} else {
+				_, err = stdcopy.StdCopy(stdout, stderr, output)
+				if err != nil && !errors.Is(err, ErrOutputLimitExceeded) {
+					log.Printf("failed to copy stdout: %+v\n", err)
+				}
+			}
+			if errors.Is(err, ErrOutputLimitExceeded) {
+				notice := connection.Stderr()
+				if connection.IsTty() {
+					notice = connection.Stdout()
+				}
+
+				_, err := io.WriteString(notice, outputLimitExceededMessage)
 				if err != nil {
-					log.Printf("failed to copy fonts: %+v", err)
+					log.Printf("failed to write output limit message: %+v\n", err)
 				}
+
+				outputErrCh <- ErrOutputLimitExceeded
+				return
 			}
 
-			_, err := io.Copy(connection.Stdout(), workspaceConnection.ReadCloser())
-			if err != nil {
-				log.Printf("failed to copy stdin: %+v\n", err)
+			if ctx.Err() != nil {
+				return
 			}
-		} else {
-			_, err := stdcopy.StdCopy(connection.Stdout(), connection.Stderr(), workspaceConnection.ReadCloser())
+
+			isOOMKilled, err := p.wwc.IsOOMKilled(ctx, workspace, workspaceConnection)
 			if err != nil {
-				log.Printf("failed to copy stdout: %+v\n", err)
+				log.Printf("failed to check oom killed: %+v\n", err)
+				return
 			}
-		}
+			if isOOMKilled {
+				if connection.IsTty() {
+					_, err := io.WriteString(connection.Stdout(), oomKilledMessage)
+					if err != nil {
+						log.Printf("failed to write oom killed message: %+v\n", err)
+					}
+				}
+
+				outputErrCh <- ErrOOMKilled
+			}
+		})
 	}()
 
 	_, err = io.Copy(workspaceConnection.WriteCloser(), connection.Stdin())
+
+	select {
+	case outputErr := <-outputErrCh:
+		if outputErr != nil {
+			return outputErr
+		}
+	default:
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to copy stdin: %+v", err)
 	}
 
 	return nil
 }
+
+// isConnectTimeout reports whether setupCtx expired while the session context is still alive.
+func isConnectTimeout(ctx context.Context, setupCtx context.Context) bool {
+	return ctx.Err() == nil && errors.Is(setupCtx.Err(), context.DeadlineExceeded)
+}
+
+// removeConnection removes a connection from the workspace and stops the workspace if no connection is left.
+func (p *Pipe) removeConnection(ws *domain.Workspace) {
+	err := ws.RemoveConnection()
+	if err != nil {
+		log.Printf("connection num missmatch: %+v", err)
+	}
+
+	if ws.ConnectionNum() == 0 {
+		err = p.ww.Stop(context.Background(), ws)
+		var transitionErr *domain.ErrInvalidTransition
+		if errors.As(err, &transitionErr) {
+			// the workspace was not started
+			return
+		}
+		if err != nil {
+			log.Printf("failed to stop workspace: %+v", err)
+		}
+	}
+}
+
+// startWorkspace starts the workspace.
+// If the image of the workspace was removed, the workspace is recreated on the current image and started.
+func (p *Pipe) startWorkspace(ctx context.Context, userName values.UserName, ws *domain.Workspace) (*domain.Workspace, error) {
+	err := p.ww.Start(ctx, ws)
+	if err == nil {
+		return ws, nil
+	}
+	if !errors.Is(err, workspace.ErrImageNotFound) {
+		return nil, err
+	}
+
+	log.Printf("image of %s not found, recreating: %+v\n", ws.Name(), err)
+
+	ws, err = p.ww.Recreate(ctx, ws)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to recreate workspace: %v", ErrWorkspaceUnrecoverable, err)
+	}
+
+	err = p.sw.Set(ctx, userName, ws)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set workspace: %w", err)
+	}
+
+	err = p.ww.Start(ctx, ws)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to start recreated workspace: %v", ErrWorkspaceUnrecoverable, err)
+	}
+
+	return ws, nil
+}
@@ -0,0 +1,696 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store/mock_store"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/mazrean/separated-webshell/workspace/mock_workspace"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestPipe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OutputLimit", testPipeOutputLimit)
+	t.Run("TerminalTheme", testPipeTerminalTheme)
+	t.Run("StartWorkspace", testStartWorkspace)
+	t.Run("ConnectTimeout", testPipeConnectTimeout)
+	t.Run("Maintenance", testPipeMaintenance)
+	t.Run("InitialWindow", testPipeInitialWindow)
+	t.Run("QuotaExhausted", testPipeQuotaExhausted)
+	t.Run("OutputPanic", testPipeOutputPanic)
+	t.Run("SessionHooks", testPipeSessionHooks)
+}
+
+func testPipeOutputLimit(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	tests := []struct {
+		description string
+		isTty       bool
+		outputLimit int64
+		output      string
+		expected    string
+		err         error
+	}{
+		{
+			description: "output within limit",
+			isTty:       true,
+			outputLimit: 16,
+			output:      "y\r\ny\r\n",
+			expected:    "y\r\ny\r\n",
+		},
+		{
+			description: "output exceeds limit",
+			isTty:       true,
+			outputLimit: 16,
+			output:      strings.Repeat("y\r\n", 100),
+			expected:    strings.Repeat("y\r\n", 5) + "y" + outputLimitExceededMessage,
+			err:         ErrOutputLimitExceeded,
+		},
+		{
+			description: "no limit",
+			isTty:       true,
+			outputLimit: 0,
+			output:      strings.Repeat("y\r\n", 100),
+			expected:    strings.Repeat("y\r\n", 100),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+			workspace.Status = values.StatusUp
+			workspaceConnection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(
+				nopWriteCloser{Writer: io.Discard},
+				io.NopCloser(strings.NewReader(test.output)),
+			))
+
+			mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+			mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).Return(workspaceConnection, nil)
+			mockConnection.EXPECT().IsOOMKilled(gomock.Any(), workspace, workspaceConnection).Return(false, nil).AnyTimes()
+			mockConnection.EXPECT().Disconnect(gomock.Any(), workspaceConnection).Return(nil)
+			mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+
+			stdinReader, stdinWriter := io.Pipe()
+			stdout := &bytes.Buffer{}
+			connection := domain.NewConnection(test.isTty, values.NewConnectionIO(stdinReader, stdout, stdout, stdinWriter.Close))
+
+			p := &Pipe{
+				sw:          mockStore,
+				wwc:         mockConnection,
+				ww:          mockWorkspace,
+				outputLimit: test.outputLimit,
+			}
+
+			err := p.Pipe(context.Background(), userName, connection)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expected, stdout.String())
+		})
+	}
+}
+
+func testPipeTerminalTheme(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	theme, err := values.NewTerminalTheme("#ffffff", "#000000", []string{"#000000", "#cd3131"})
+	if err != nil {
+		t.Fatalf("failed to create test theme: %s", err)
+	}
+
+	tests := []struct {
+		description string
+		isTty       bool
+		theme       *values.TerminalTheme
+		expected    string
+	}{
+		{
+			description: "theme is written before output",
+			isTty:       true,
+			theme:       theme,
+			expected:    "\x1b]4;0;#000000\x07\x1b]4;1;#cd3131\x07\x1b]10;#000000\x07\x1b]11;#ffffff\x07output",
+		},
+		{
+			description: "no theme",
+			isTty:       true,
+			expected:    "output",
+		},
+		{
+			description: "theme is not written without tty",
+			isTty:       false,
+			theme:       theme,
+			expected:    "output",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			output := "output"
+			if !test.isTty {
+				buf := &bytes.Buffer{}
+				_, err := stdcopy.NewStdWriter(buf, stdcopy.Stdout).Write([]byte(output))
+				if err != nil {
+					t.Fatalf("failed to create test output: %s", err)
+				}
+				output = buf.String()
+			}
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+			workspace.Status = values.StatusUp
+			workspaceConnection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(
+				nopWriteCloser{Writer: io.Discard},
+				io.NopCloser(strings.NewReader(output)),
+			))
+
+			mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+			mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).Return(workspaceConnection, nil)
+			mockConnection.EXPECT().IsOOMKilled(gomock.Any(), workspace, workspaceConnection).Return(false, nil)
+			mockConnection.EXPECT().Disconnect(gomock.Any(), workspaceConnection).Return(nil)
+			mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+
+			stdinReader, stdinWriter := io.Pipe()
+			stdout := &bytes.Buffer{}
+			connection := domain.NewConnection(test.isTty, values.NewConnectionIO(stdinReader, stdout, stdout, stdinWriter.Close))
+
+			p := &Pipe{
+				sw:    mockStore,
+				wwc:   mockConnection,
+				ww:    mockWorkspace,
+				theme: test.theme,
+			}
+
+			err := p.Pipe(context.Background(), userName, connection)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.expected, stdout.String())
+		})
+	}
+}
+
+func testStartWorkspace(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	imageNotFoundErr := fmt.Errorf("failed to start container: %w", workspace.ErrImageNotFound)
+
+	tests := []struct {
+		description string
+		startErr    error
+		isRecreate  bool
+		recreateErr error
+		restartErr  error
+		isRecreated bool
+		isErr       bool
+		err         error
+	}{
+		{
+			description: "start workspace",
+		},
+		{
+			description: "start error",
+			startErr:    errors.New("start error"),
+			isErr:       true,
+		},
+		{
+			description: "image not found",
+			startErr:    imageNotFoundErr,
+			isRecreate:  true,
+			isRecreated: true,
+		},
+		{
+			description: "recreate error",
+			startErr:    imageNotFoundErr,
+			isRecreate:  true,
+			recreateErr: errors.New("recreate error"),
+			isErr:       true,
+			err:         ErrWorkspaceUnrecoverable,
+		},
+		{
+			description: "start recreated workspace error",
+			startErr:    imageNotFoundErr,
+			isRecreate:  true,
+			restartErr:  errors.New("start error"),
+			isErr:       true,
+			err:         ErrWorkspaceUnrecoverable,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+			userName := values.UserName("test")
+			ws := domain.NewWorkspace("container_id", "user-test", userName)
+			newWS := domain.NewWorkspace("new_container_id", "user-test", userName)
+
+			mockWorkspace.EXPECT().Start(gomock.Any(), ws).Return(test.startErr)
+			if test.isRecreate {
+				if test.recreateErr != nil {
+					mockWorkspace.EXPECT().Recreate(gomock.Any(), ws).Return(nil, test.recreateErr)
+				} else {
+					mockWorkspace.EXPECT().Recreate(gomock.Any(), ws).Return(newWS, nil)
+					mockStore.EXPECT().Set(gomock.Any(), userName, newWS).Return(nil)
+					mockWorkspace.EXPECT().Start(gomock.Any(), newWS).Return(test.restartErr)
+				}
+			}
+
+			p := &Pipe{
+				sw: mockStore,
+				ww: mockWorkspace,
+			}
+
+			actual, err := p.startWorkspace(context.Background(), userName, ws)
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			if test.isRecreated {
+				assert.Equal(t, newWS, actual)
+			} else {
+				assert.Equal(t, ws, actual)
+			}
+		})
+	}
+}
+
+func testPipeConnectTimeout(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	tests := []struct {
+		description string
+		status      values.WorkspaceStatus
+		isStartSlow bool
+		isStop      bool
+	}{
+		{
+			description: "connect timeout",
+			status:      values.StatusUp,
+			isStop:      true,
+		},
+		{
+			description: "start timeout",
+			status:      values.StatusDown,
+			isStartSlow: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+			workspace.Status = test.status
+
+			waitDone := func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+			if test.isStartSlow {
+				mockWorkspace.EXPECT().Start(gomock.Any(), workspace).DoAndReturn(func(ctx context.Context, _ *domain.Workspace) error {
+					return waitDone(ctx)
+				})
+			} else {
+				mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).DoAndReturn(func(ctx context.Context, _ *domain.Workspace, _ string) (*domain.WorkspaceConnection, error) {
+					return nil, waitDone(ctx)
+				})
+			}
+			if test.isStop {
+				mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+			}
+
+			stdinReader, stdinWriter := io.Pipe()
+			connection := domain.NewConnection(true, values.NewConnectionIO(stdinReader, io.Discard, io.Discard, stdinWriter.Close))
+
+			p := &Pipe{
+				sw:             mockStore,
+				wwc:            mockConnection,
+				ww:             mockWorkspace,
+				connectTimeout: 10 * time.Millisecond,
+			}
+
+			err := p.Pipe(context.Background(), userName, connection)
+			assert.ErrorIs(t, err, ErrConnectTimeout)
+			assert.Equal(t, int32(0), workspace.ConnectionNum())
+		})
+	}
+}
+
+func testPipeMaintenance(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	tests := []struct {
+		description string
+		isTty       bool
+		message     string
+		expected    string
+	}{
+		{
+			description: "custom message",
+			isTty:       true,
+			message:     "back soon",
+			expected:    "\r\nback soon\r\n",
+		},
+		{
+			description: "default message",
+			isTty:       true,
+			expected:    "\r\n" + defaultMaintenanceMessage + "\r\n",
+		},
+		{
+			description: "message is not written without tty",
+			isTty:       false,
+			message:     "back soon",
+			expected:    "",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// docker and the store must not be touched
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			maintenance := NewMaintenance()
+			maintenance.SetMaintenance(true, test.message)
+
+			stdout := &bytes.Buffer{}
+			connection := domain.NewConnection(test.isTty, values.NewConnectionIO(strings.NewReader(""), stdout, stdout, func() error {
+				return nil
+			}))
+
+			p := &Pipe{
+				sw:          mockStore,
+				wwc:         mockConnection,
+				ww:          mockWorkspace,
+				maintenance: maintenance,
+			}
+
+			err := p.Pipe(context.Background(), "test", connection)
+			assert.ErrorIs(t, err, ErrMaintenance)
+			assert.Equal(t, test.expected, stdout.String())
+		})
+	}
+}
+
+func testPipeInitialWindow(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_store.NewMockIWorkspace(ctrl)
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+	mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+	userName := values.UserName("test")
+	workspace := domain.NewWorkspace("container_id", "user-test", userName)
+	workspace.Status = values.StatusUp
+	workspaceConnection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(
+		nopWriteCloser{Writer: io.Discard},
+		io.NopCloser(strings.NewReader("output")),
+	))
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdout := &bytes.Buffer{}
+	connection := domain.NewConnection(true, values.NewConnectionIO(stdinReader, stdout, stdout, stdinWriter.Close))
+	initialWindow := values.NewWindow(40, 120)
+	connection.SetInitialWindow(initialWindow)
+
+	mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+	mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).Return(workspaceConnection, nil)
+	mockConnection.
+		EXPECT().
+		Resize(gomock.Any(), workspaceConnection, initialWindow).
+		Do(func(context.Context, *domain.WorkspaceConnection, *values.Window) {
+			assert.Empty(t, stdout.String(), "initial window must be applied before any output")
+		}).
+		Return(nil).
+		Times(1)
+	mockConnection.EXPECT().IsOOMKilled(gomock.Any(), workspace, workspaceConnection).Return(false, nil)
+	mockConnection.EXPECT().Disconnect(gomock.Any(), workspaceConnection).Return(nil)
+	mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+
+	p := &Pipe{
+		sw:  mockStore,
+		wwc: mockConnection,
+		ww:  mockWorkspace,
+	}
+
+	err := p.Pipe(context.Background(), userName, connection)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "output", stdout.String())
+	assert.NotZero(t, workspaceConnection.Timing.FirstByteDuration)
+}
+
+func testPipeQuotaExhausted(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// docker and the store must not be touched
+	mockStore := mock_store.NewMockIWorkspace(ctrl)
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+	mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+	quota := newQuotaTracker(time.Minute)
+	quota.Record("test", time.Minute)
+
+	stdout := &bytes.Buffer{}
+	connection := domain.NewConnection(true, values.NewConnectionIO(strings.NewReader(""), stdout, stdout, func() error {
+		return nil
+	}))
+
+	p := &Pipe{
+		sw:    mockStore,
+		wwc:   mockConnection,
+		ww:    mockWorkspace,
+		quota: quota,
+	}
+
+	err := p.Pipe(context.Background(), "test", connection)
+	assert.ErrorIs(t, err, ErrQuotaExhausted)
+	assert.Equal(t, quotaExhaustedMessage, stdout.String())
+}
+
+func testPipeOutputPanic(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_store.NewMockIWorkspace(ctrl)
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+	mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+	userName := values.UserName("test")
+	workspace := domain.NewWorkspace("container_id", "user-test", userName)
+	workspace.Status = values.StatusUp
+	workspaceConnection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(
+		nopWriteCloser{Writer: io.Discard},
+		io.NopCloser(strings.NewReader("output")),
+	))
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdout := &bytes.Buffer{}
+	connection := domain.NewConnection(true, values.NewConnectionIO(stdinReader, stdout, stdout, stdinWriter.Close))
+
+	mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+	mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).Return(workspaceConnection, nil)
+	mockConnection.
+		EXPECT().
+		IsOOMKilled(gomock.Any(), workspace, workspaceConnection).
+		DoAndReturn(func(context.Context, *domain.Workspace, *domain.WorkspaceConnection) (bool, error) {
+			panic("test panic")
+		})
+	mockConnection.EXPECT().Disconnect(gomock.Any(), workspaceConnection).Return(nil)
+	mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+
+	p := &Pipe{
+		sw:  mockStore,
+		wwc: mockConnection,
+		ww:  mockWorkspace,
+	}
+
+	panicCount := testutil.ToFloat64(panicCounter.WithLabelValues("output"))
+
+	// the session ends since the connection is closed even if the output panics
+	err := p.Pipe(context.Background(), userName, connection)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "output", stdout.String())
+	assert.Equal(t, panicCount+1, testutil.ToFloat64(panicCounter.WithLabelValues("output")))
+}
+
+func testPipeSessionHooks(t *testing.T) {
+	t.Parallel()
+	t.Helper()
+
+	tests := []struct {
+		description       string
+		startErr          error
+		isStartErrorFatal bool
+		isEnded           bool
+		isErr             bool
+	}{
+		{
+			description: "session",
+			isEnded:     true,
+		},
+		{
+			description: "start hook error",
+			startErr:    errors.New("start hook error"),
+			isEnded:     true,
+		},
+		{
+			description:       "fatal start hook error",
+			startErr:          errors.New("start hook error"),
+			isStartErrorFatal: true,
+			isErr:             true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+			workspace.Status = values.StatusUp
+			workspaceConnection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(
+				nopWriteCloser{Writer: io.Discard},
+				io.NopCloser(strings.NewReader("output")),
+			))
+
+			stdinReader, stdinWriter := io.Pipe()
+			stdout := &bytes.Buffer{}
+			connection := domain.NewConnection(true, values.NewConnectionIO(stdinReader, stdout, stdout, stdinWriter.Close))
+
+			mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+			mockConnection.EXPECT().Connect(gomock.Any(), workspace, gomock.Any()).Return(workspaceConnection, nil)
+			mockConnection.EXPECT().IsOOMKilled(gomock.Any(), workspace, workspaceConnection).Return(false, nil).AnyTimes()
+			mockConnection.EXPECT().Disconnect(gomock.Any(), workspaceConnection).Return(nil)
+			mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil)
+
+			var (
+				startedSessionID string
+				endedSessionID   string
+				summary          *SessionSummary
+			)
+			p := &Pipe{
+				sw:  mockStore,
+				wwc: mockConnection,
+				ww:  mockWorkspace,
+				hooks: &SessionHooks{
+					OnSessionStart: func(ctx context.Context, hookUserName values.UserName, sessionID string) error {
+						assert.Equal(t, userName, hookUserName)
+						startedSessionID = sessionID
+						return test.startErr
+					},
+					OnSessionEnd: func(ctx context.Context, hookUserName values.UserName, sessionID string, sessionSummary *SessionSummary) error {
+						assert.Equal(t, userName, hookUserName)
+						endedSessionID = sessionID
+						summary = sessionSummary
+						return errors.New("end hook error")
+					},
+					IsStartErrorFatal: test.isStartErrorFatal,
+				},
+			}
+
+			err := p.Pipe(context.Background(), userName, connection)
+			if test.isErr {
+				assert.ErrorIs(t, err, test.startErr)
+			} else {
+				// the errors of the hooks do not fail the session
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, "exec_id", startedSessionID)
+			if !test.isEnded {
+				assert.Empty(t, endedSessionID)
+				assert.Empty(t, stdout.String())
+				return
+			}
+
+			assert.Equal(t, "exec_id", endedSessionID)
+			assert.Equal(t, "output", stdout.String())
+			if assert.NotNil(t, summary) {
+				assert.NoError(t, summary.Err)
+				assert.False(t, summary.StartedAt.IsZero())
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+var strDailyShellQuota = os.Getenv("DAILY_SHELL_QUOTA")
+
+var (
+	// ErrQuotaExhausted the user used up the shell time of the day
+	ErrQuotaExhausted = errors.New("quota exhausted")
+)
+
+const (
+	quotaExhaustedMessage = "\r\nYour shell time for today is used up. It is reset at 00:00 UTC.\r\n"
+	quotaDayFormat        = "2006-01-02"
+)
+
+// quotaTracker tracks the shell time of each user per day in UTC.
+// The usage is kept in memory, so it is reset when the server restarts.
+type quotaTracker struct {
+	quota time.Duration
+	now   func() time.Time
+
+	locker sync.Mutex
+	// day the day the usage is counted for
+	day   string
+	usage map[values.UserName]time.Duration
+}
+
+func newQuotaTracker(quota time.Duration) *quotaTracker {
+	return &quotaTracker{
+		quota: quota,
+		now:   time.Now,
+		usage: map[values.UserName]time.Duration{},
+	}
+}
+
+// resetIfNewDay clears the usage when the day changed. The caller must hold the lock.
+func (qt *quotaTracker) resetIfNewDay() {
+	day := qt.now().UTC().Format(quotaDayFormat)
+	if day != qt.day {
+		qt.day = day
+		qt.usage = map[values.UserName]time.Duration{}
+	}
+}
+
+// Remaining returns the shell time left for the user today. 0 if the quota is exhausted.
+func (qt *quotaTracker) Remaining(userName values.UserName) time.Duration {
+	qt.locker.Lock()
+	defer qt.locker.Unlock()
+
+	qt.resetIfNewDay()
+
+	remaining := qt.quota - qt.usage[userName]
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// Record adds the elapsed shell time of a session to the usage of the user.
+// A session over midnight is counted for the day it ends.
+func (qt *quotaTracker) Record(userName values.UserName, elapsed time.Duration) {
+	qt.locker.Lock()
+	defer qt.locker.Unlock()
+
+	qt.resetIfNewDay()
+
+	qt.usage[userName] += elapsed
+}
+
+// limitSession closes the connection when the remaining shell time of the user runs out.
+// Concurrent sessions of the same user are each bounded by the time remaining at their start.
+func (p *Pipe) limitSession(ctx context.Context, remaining time.Duration, connection *domain.Connection) {
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	if connection.IsTty() {
+		_, err := io.WriteString(connection.Stdout(), quotaExhaustedMessage)
+		if err != nil {
+			log.Printf("failed to write quota exhausted message: %+v\n", err)
+		}
+	}
+
+	err := connection.Close()
+	if err != nil && err != io.EOF {
+		log.Printf("failed to close connection: %+v\n", err)
+	}
+}
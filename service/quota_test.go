@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 7, 1, 23, 0, 0, 0, time.UTC)
+	qt := newQuotaTracker(time.Hour)
+	qt.now = func() time.Time {
+		return now
+	}
+
+	var userName values.UserName = "test"
+	var otherUserName values.UserName = "other"
+
+	assert.Equal(t, time.Hour, qt.Remaining(userName))
+
+	qt.Record(userName, 40*time.Minute)
+	assert.Equal(t, 20*time.Minute, qt.Remaining(userName))
+	assert.Equal(t, time.Hour, qt.Remaining(otherUserName))
+
+	qt.Record(userName, 30*time.Minute)
+	assert.Equal(t, time.Duration(0), qt.Remaining(userName))
+
+	// the usage is reset at 00:00 UTC
+	now = now.Add(time.Hour)
+	assert.Equal(t, time.Hour, qt.Remaining(userName))
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+var strResizeDebounce = os.Getenv("RESIZE_DEBOUNCE")
+
+const defaultResizeDebounce = 50 * time.Millisecond
+
+// resizeWorkspace applies the window sizes from windowCh to the workspace connection until windowCh is closed.
+// Sizes received within resizeDebounce of each other are coalesced and only the latest one is applied.
+// Sizes equal to the last applied one are skipped, starting from applied,
+// so the initial size also sent as the first window event is not applied twice.
+func (p *Pipe) resizeWorkspace(ctx context.Context, workspaceConnection *domain.WorkspaceConnection, applied *values.Window, windowCh <-chan *values.Window) {
+	resize := func(win *values.Window) {
+		if applied != nil && applied.Height() == win.Height() && applied.Width() == win.Width() {
+			return
+		}
+
+		err := p.wwc.Resize(ctx, workspaceConnection, win)
+		if err != nil {
+			log.Printf("failed to resize window: %+v", err)
+			return
+		}
+		applied = win
+	}
+
+	if p.resizeDebounce <= 0 {
+		for win := range windowCh {
+			resize(win)
+		}
+		return
+	}
+
+	var (
+		pending *values.Window
+		timerCh <-chan time.Time
+	)
+	for {
+		select {
+		case win, ok := <-windowCh:
+			if !ok {
+				if pending != nil {
+					resize(pending)
+				}
+				return
+			}
+
+			pending = win
+			timerCh = time.After(p.resizeDebounce)
+		case <-timerCh:
+			resize(pending)
+			pending = nil
+			timerCh = nil
+		}
+	}
+}
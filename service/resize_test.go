@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace/mock_workspace"
+)
+
+func TestResizeWorkspace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description    string
+		resizeDebounce time.Duration
+		initial        *values.Window
+		windows        []*values.Window
+		expected       []*values.Window
+	}{
+		{
+			description:    "burst is coalesced",
+			resizeDebounce: 50 * time.Millisecond,
+			windows: []*values.Window{
+				values.NewWindow(24, 80),
+				values.NewWindow(25, 81),
+				values.NewWindow(26, 82),
+				values.NewWindow(40, 120),
+			},
+			expected: []*values.Window{
+				values.NewWindow(40, 120),
+			},
+		},
+		{
+			description:    "no debounce",
+			resizeDebounce: 0,
+			windows: []*values.Window{
+				values.NewWindow(24, 80),
+				values.NewWindow(40, 120),
+			},
+			expected: []*values.Window{
+				values.NewWindow(24, 80),
+				values.NewWindow(40, 120),
+			},
+		},
+		{
+			description:    "initial size is not applied twice",
+			resizeDebounce: 0,
+			initial:        values.NewWindow(24, 80),
+			windows: []*values.Window{
+				values.NewWindow(24, 80),
+				values.NewWindow(40, 120),
+				values.NewWindow(40, 120),
+			},
+			expected: []*values.Window{
+				values.NewWindow(40, 120),
+			},
+		},
+		{
+			description:    "coalesced size equal to initial size",
+			resizeDebounce: 50 * time.Millisecond,
+			initial:        values.NewWindow(24, 80),
+			windows: []*values.Window{
+				values.NewWindow(24, 80),
+			},
+			expected: []*values.Window{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConnection := mock_workspace.NewMockIWorkspaceConnection(ctrl)
+
+			workspaceConnection := domain.NewWorkspaceConnection("exec_id", nil)
+
+			applied := make(chan struct{}, len(test.expected))
+			calls := make([]*gomock.Call, 0, len(test.expected))
+			for _, win := range test.expected {
+				calls = append(calls, mockConnection.
+					EXPECT().
+					Resize(gomock.Any(), workspaceConnection, win).
+					Do(func(context.Context, *domain.WorkspaceConnection, *values.Window) {
+						applied <- struct{}{}
+					}).
+					Return(nil))
+			}
+			gomock.InOrder(calls...)
+
+			p := &Pipe{
+				wwc:            mockConnection,
+				resizeDebounce: test.resizeDebounce,
+			}
+
+			windowCh := make(chan *values.Window)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				p.resizeWorkspace(context.Background(), workspaceConnection, test.initial, windowCh)
+			}()
+
+			for _, win := range test.windows {
+				windowCh <- win
+			}
+
+			// the latest size is applied after the debounce window without closing the channel
+			for range test.expected {
+				select {
+				case <-applied:
+				case <-time.After(time.Second):
+					t.Fatal("window size is not applied")
+				}
+			}
+
+			close(windowCh)
+			<-done
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	panicRestartInterval    = 100 * time.Millisecond
+	maxPanicRestartInterval = 10 * time.Second
+)
+
+var panicCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Help:      "Number of panics recovered in background goroutines.",
+	Namespace: "webshell",
+	Name:      "goroutine_panic_total",
+}, []string{"goroutine"})
+
+// safeGo runs fn in a goroutine and restarts it if it panics.
+// The interval between restarts doubles up to maxPanicRestartInterval. It is not restarted after ctx is done.
+func safeGo(ctx context.Context, name string, fn func(ctx context.Context)) {
+	go func() {
+		interval := panicRestartInterval
+		for {
+			if !runRecovered(ctx, name, fn) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			interval *= 2
+			if interval > maxPanicRestartInterval {
+				interval = maxPanicRestartInterval
+			}
+		}
+	}()
+}
+
+// runRecovered runs fn and reports whether it panicked.
+func runRecovered(ctx context.Context, name string, fn func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		panicked = true
+		panicCounter.WithLabelValues(name).Inc()
+		log.Printf("panic in %s: %v\n%s", name, r, debug.Stack())
+	}()
+
+	fn(ctx)
+
+	return false
+}
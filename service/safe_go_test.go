@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeGo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("restart after panic", testSafeGoRestart)
+	t.Run("no restart after cancel", testSafeGoCancel)
+}
+
+func testSafeGoRestart(t *testing.T) {
+	t.Helper()
+	t.Parallel()
+
+	var count int32
+	done := make(chan struct{})
+	safeGo(context.Background(), "test", func(ctx context.Context) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			panic("test panic")
+		}
+		close(done)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("goroutine was not restarted")
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func testSafeGoCancel(t *testing.T) {
+	t.Helper()
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var count int32
+	safeGo(ctx, "test", func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+		cancel()
+		panic("test panic")
+	})
+
+	time.Sleep(2 * panicRestartInterval)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
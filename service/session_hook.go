@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// SessionSummary the result of a session passed to OnSessionEnd.
+type SessionSummary struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	// Err the error the session ended with. nil if the client closed the session.
+	Err error
+}
+
+// SessionHooks callbacks on the lifecycle of sessions(e.g. notifying a billing system or sending a webhook).
+// The session ID is the ID of the exec of the session. The callbacks run synchronously in the session, so they should return quickly.
+type SessionHooks struct {
+	// OnSessionStart is called after the session is attached to the workspace.
+	OnSessionStart func(ctx context.Context, userName values.UserName, sessionID string) error
+	// OnSessionEnd is called when a session started with OnSessionStart ends.
+	OnSessionEnd func(ctx context.Context, userName values.UserName, sessionID string, summary *SessionSummary) error
+	// IsStartErrorFatal if true, the session is closed when OnSessionStart fails. Otherwise errors of the callbacks are only logged.
+	IsStartErrorFatal bool
+}
+
+// sessionHooks nil calls no hooks
+var sessionHooks *SessionHooks
+
+// SetSessionHooks sets the callbacks of the sessions of pipes created after this call.
+func SetSessionHooks(hooks *SessionHooks) {
+	sessionHooks = hooks
+}
@@ -16,7 +16,8 @@ import (
 )
 
 type IUser interface {
-	New(ctx context.Context, name values.UserName, password values.Password) error
+	New(ctx context.Context, name values.UserName, password values.Password, metadata domain.ContainerMetadata) error
+	SetMetadata(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error
 	ResetContainer(ctx context.Context, userName values.UserName) error
 	Auth(ctx context.Context, name values.UserName, password values.Password) (bool, error)
 }
@@ -24,16 +25,20 @@ type IUser interface {
 type User struct {
 	ww workspace.IWorkspace
 	sw store.IWorkspace
+	sm store.IMetadata
 	ru repository.IUser
 	rt repository.ITransaction
+	m  *Maintenance
 }
 
-func NewUser(ww workspace.IWorkspace, sw store.IWorkspace, ru repository.IUser, rt repository.ITransaction) *User {
+func NewUser(ww workspace.IWorkspace, sw store.IWorkspace, sm store.IMetadata, ru repository.IUser, rt repository.ITransaction, m *Maintenance) *User {
 	return &User{
 		ww: ww,
 		sw: sw,
+		sm: sm,
 		ru: ru,
 		rt: rt,
+		m:  m,
 	}
 }
 
@@ -42,9 +47,17 @@ var (
 	ErrUserExist = errors.New("user exist")
 	// ErrWorkspaceExist workspace already exists
 	ErrWorkspaceExist = errors.New("workspace exist")
+	// ErrInsufficientMemory no memory is left for a new workspace
+	ErrInsufficientMemory = errors.New("insufficient memory")
 )
 
-func (u *User) New(ctx context.Context, name values.UserName, password values.Password) error {
+// New creates the user and the workspace. The metadata is kept in the metadata store, so it is not lost when the workspace is reset.
+func (u *User) New(ctx context.Context, name values.UserName, password values.Password, metadata domain.ContainerMetadata) error {
+	isMaintenance, _ := u.m.Maintenance()
+	if isMaintenance {
+		return ErrMaintenance
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
 	if err != nil {
 		return fmt.Errorf("failed to hash password")
@@ -73,6 +86,13 @@ func (u *User) New(ctx context.Context, name values.UserName, password values.Pa
 			return fmt.Errorf("failed to set workspace: %w", err)
 		}
 
+		if len(metadata) != 0 {
+			err = u.sm.Set(ctx, user.GetName(), metadata)
+			if err != nil {
+				return fmt.Errorf("failed to set metadata: %w", err)
+			}
+		}
+
 		return nil
 	})
 	if errors.Is(err, repository.ErrUserExist) {
@@ -81,6 +101,9 @@ func (u *User) New(ctx context.Context, name values.UserName, password values.Pa
 	if errors.Is(err, workspace.ErrWorkspaceExist) {
 		return ErrWorkspaceExist
 	}
+	if errors.Is(err, workspace.ErrInsufficientMemory) {
+		return ErrInsufficientMemory
+	}
 	if err != nil {
 		return fmt.Errorf("failed in transaction: %w", err)
 	}
@@ -107,6 +130,30 @@ func (u *User) ResetContainer(ctx context.Context, userName values.UserName) err
 	return nil
 }
 
+// SetMetadata replaces the metadata of the user. Empty metadata removes it.
+func (u *User) SetMetadata(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error {
+	_, err := u.sw.Get(ctx, userName)
+	if err != nil {
+		return ErrInvalidUser
+	}
+
+	if len(metadata) == 0 {
+		err = u.sm.Delete(ctx, userName)
+		if err != nil {
+			return fmt.Errorf("failed to delete metadata: %w", err)
+		}
+
+		return nil
+	}
+
+	err = u.sm.Set(ctx, userName, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to set metadata: %w", err)
+	}
+
+	return nil
+}
+
 var (
 	// ErrInvalidUser invalid user
 	ErrInvalidUser = errors.New("invalid user")
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/repository/mock_repository"
+	"github.com/mazrean/separated-webshell/store"
+	"github.com/mazrean/separated-webshell/store/mock_store"
+	"github.com/mazrean/separated-webshell/workspace/mock_workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserNewMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		metadata    domain.ContainerMetadata
+		createErr   error
+		isSet       bool
+		setErr      error
+		isErr       bool
+	}{
+		{
+			description: "with metadata",
+			metadata:    domain.ContainerMetadata{"course": "cs101"},
+			isSet:       true,
+		},
+		{
+			description: "without metadata",
+		},
+		{
+			description: "workspace create error",
+			metadata:    domain.ContainerMetadata{"course": "cs101"},
+			createErr:   errors.New("create error"),
+			isErr:       true,
+		},
+		{
+			description: "metadata set error",
+			metadata:    domain.ContainerMetadata{"course": "cs101"},
+			isSet:       true,
+			setErr:      errors.New("set error"),
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+			mockWorkspaceStore := mock_store.NewMockIWorkspace(ctrl)
+			mockMetadataStore := mock_store.NewMockIMetadata(ctrl)
+			mockUserRepository := mock_repository.NewMockIUser(ctrl)
+			mockTransaction := mock_repository.NewMockITransaction(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+
+			mockTransaction.
+				EXPECT().
+				Transaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				})
+			mockUserRepository.
+				EXPECT().
+				Create(gomock.Any(), gomock.Any()).
+				Return(nil)
+			if test.createErr != nil {
+				mockWorkspace.
+					EXPECT().
+					Create(gomock.Any(), userName).
+					Return(nil, test.createErr)
+			} else {
+				mockWorkspace.
+					EXPECT().
+					Create(gomock.Any(), userName).
+					Return(workspace, nil)
+				mockWorkspaceStore.
+					EXPECT().
+					Set(gomock.Any(), userName, workspace).
+					Return(nil)
+			}
+			if test.isSet {
+				mockMetadataStore.
+					EXPECT().
+					Set(gomock.Any(), userName, test.metadata).
+					Return(test.setErr)
+			}
+
+			u := NewUser(mockWorkspace, mockWorkspaceStore, mockMetadataStore, mockUserRepository, mockTransaction, NewMaintenance())
+
+			err := u.New(context.Background(), userName, "password", test.metadata)
+			if test.isErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserSetMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		metadata    domain.ContainerMetadata
+		getErr      error
+		isDeleted   bool
+		isSet       bool
+		err         error
+	}{
+		{
+			description: "set",
+			metadata:    domain.ContainerMetadata{"course": "cs101"},
+			isSet:       true,
+		},
+		{
+			description: "empty metadata",
+			metadata:    domain.ContainerMetadata{},
+			isDeleted:   true,
+		},
+		{
+			description: "unknown user",
+			metadata:    domain.ContainerMetadata{"course": "cs101"},
+			getErr:      store.ErrWorkspaceNotFound,
+			err:         ErrInvalidUser,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockWorkspaceStore := mock_store.NewMockIWorkspace(ctrl)
+			mockMetadataStore := mock_store.NewMockIMetadata(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+
+			mockWorkspaceStore.
+				EXPECT().
+				Get(gomock.Any(), userName).
+				Return(workspace, test.getErr)
+			if test.isDeleted {
+				mockMetadataStore.
+					EXPECT().
+					Delete(gomock.Any(), userName).
+					Return(nil)
+			}
+			if test.isSet {
+				mockMetadataStore.
+					EXPECT().
+					Set(gomock.Any(), userName, test.metadata).
+					Return(nil)
+			}
+
+			u := NewUser(nil, mockWorkspaceStore, mockMetadataStore, nil, nil, NewMaintenance())
+
+			err := u.SetMetadata(context.Background(), userName, test.metadata)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+type Version struct {
+	ww workspace.IWorkspace
+}
+
+func NewVersion(ww workspace.IWorkspace) *Version {
+	return &Version{
+		ww: ww,
+	}
+}
+
+// DaemonVersion returns the version of the container daemon.
+func (v *Version) DaemonVersion(ctx context.Context) (string, error) {
+	daemonVersion, err := v.ww.DaemonVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get daemon version: %w", err)
+	}
+
+	return daemonVersion, nil
+}
+
+// StorageDriver returns the storage driver of the container daemon.
+func (v *Version) StorageDriver(ctx context.Context) (string, error) {
+	storageDriver, err := v.ww.DetectStorageDriver(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get storage driver: %w", err)
+	}
+
+	return storageDriver, nil
+}
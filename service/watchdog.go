@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var strWatchdog = os.Getenv("WATCHDOG")
+
+const watchdogRetryInterval = 5 * time.Second
+
+var restartCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Help:      "Number of workspaces restarted by the watchdog after an unexpected exit.",
+	Namespace: "webshell",
+	Name:      "workspace_restart_total",
+})
+
+// Watchdog restarts workspaces that exited while they had connections.
+// Workspaces are stopped by the server only after the last connection is closed or while marked as stopping,
+// so any other exit with connections is unexpected(e.g. a crash of the entrypoint or a stop from outside).
+type Watchdog struct {
+	enabled bool
+	sw      store.IWorkspace
+	ww      workspace.IWorkspace
+}
+
+func NewWatchdog(sw store.IWorkspace, ww workspace.IWorkspace) (*Watchdog, error) {
+	var enabled bool
+	if len(strWatchdog) != 0 {
+		var err error
+		enabled, err = strconv.ParseBool(strWatchdog)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watchdog: %w", err)
+		}
+	}
+
+	return &Watchdog{
+		enabled: enabled,
+		sw:      sw,
+		ww:      ww,
+	}, nil
+}
+
+// Start watches the exits of workspaces in background until ctx is done. It does nothing if the watchdog is disabled.
+func (w *Watchdog) Start(ctx context.Context) {
+	if !w.enabled {
+		return
+	}
+
+	safeGo(ctx, "watchdog", w.run)
+}
+
+// run restarts the watch after watchdogRetryInterval if it fails.
+func (w *Watchdog) run(ctx context.Context) {
+	for {
+		userCh, errCh := w.ww.WatchDied(ctx)
+		for userName := range userCh {
+			w.restart(ctx, userName)
+		}
+
+		select {
+		case err := <-errCh:
+			log.Printf("watchdog stopped, retrying in %s: %+v\n", watchdogRetryInterval, err)
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchdogRetryInterval):
+		}
+	}
+}
+
+func (w *Watchdog) restart(ctx context.Context, userName values.UserName) {
+	ws, err := w.sw.Get(ctx, userName)
+	if errors.Is(err, store.ErrWorkspaceNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to get workspace: %+v\n", err)
+		return
+	}
+
+	// a workspace stopped by the server(e.g. by the memory monitor) may still be up with connections when the exit is reported
+	if ws.Status != values.StatusUp || ws.ConnectionNum() == 0 || ws.IsStopping() {
+		return
+	}
+
+	log.Printf("workspace %s exited with %d connections, restarting\n", ws.Name(), ws.ConnectionNum())
+
+	// stopped first so that the status and the container metrics follow the exit
+	err = w.ww.Stop(ctx, ws)
+	if err != nil {
+		log.Printf("failed to stop exited workspace: %+v\n", err)
+		return
+	}
+
+	err = w.ww.Start(ctx, ws)
+	if err != nil {
+		log.Printf("failed to restart workspace: %+v\n", err)
+		return
+	}
+	restartCounter.Inc()
+
+	// the connections may have been closed by the exit during the restart,
+	// and their stop failed while the workspace was down, so it is stopped here instead
+	if ws.ConnectionNum() == 0 {
+		err = w.ww.Stop(ctx, ws)
+		if err != nil {
+			log.Printf("failed to stop restarted workspace without connections: %+v\n", err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+	"github.com/mazrean/separated-webshell/store/mock_store"
+	"github.com/mazrean/separated-webshell/workspace/mock_workspace"
+)
+
+func TestWatchdogRestart(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description   string
+		status        values.WorkspaceStatus
+		connectionNum int
+		isStopping    bool
+		getErr        error
+		isRestarted   bool
+		// isDisconnected whether the connections are closed during the restart
+		isDisconnected bool
+	}{
+		{
+			description:   "exited with connections",
+			status:        values.StatusUp,
+			connectionNum: 1,
+			isRestarted:   true,
+		},
+		{
+			description:    "connections dropped to 0 during restart",
+			status:         values.StatusUp,
+			connectionNum:  1,
+			isRestarted:    true,
+			isDisconnected: true,
+		},
+		{
+			description:   "stopped after last connection",
+			status:        values.StatusUp,
+			connectionNum: 0,
+		},
+		{
+			description:   "stopped by the server with connections",
+			status:        values.StatusUp,
+			connectionNum: 1,
+			isStopping:    true,
+		},
+		{
+			description:   "stopped workspace",
+			status:        values.StatusDown,
+			connectionNum: 1,
+		},
+		{
+			description: "unknown workspace",
+			getErr:      store.ErrWorkspaceNotFound,
+		},
+		{
+			description: "get error",
+			getErr:      errors.New("get error"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockIWorkspace(ctrl)
+			mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+			userName := values.UserName("test")
+			workspace := domain.NewWorkspace("container_id", "user-test", userName)
+			workspace.Status = test.status
+			workspace.SetStopping(test.isStopping)
+			for i := 0; i < test.connectionNum; i++ {
+				err := workspace.AddConnection()
+				if err != nil {
+					t.Fatalf("failed to add connection: %s", err)
+				}
+			}
+
+			if test.getErr != nil {
+				mockStore.EXPECT().Get(gomock.Any(), userName).Return(nil, test.getErr)
+			} else {
+				mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+			}
+			if test.isRestarted && !test.isDisconnected {
+				gomock.InOrder(
+					mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil),
+					mockWorkspace.EXPECT().Start(gomock.Any(), workspace).Return(nil),
+				)
+			}
+			if test.isRestarted && test.isDisconnected {
+				gomock.InOrder(
+					mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil),
+					mockWorkspace.
+						EXPECT().
+						Start(gomock.Any(), workspace).
+						DoAndReturn(func(ctx context.Context, ws *domain.Workspace) error {
+							// the stop of the last connection fails while the workspace is down
+							for ws.ConnectionNum() > 0 {
+								err := ws.RemoveConnection()
+								if err != nil {
+									t.Fatalf("failed to remove connection: %s", err)
+								}
+							}
+							return nil
+						}),
+					mockWorkspace.EXPECT().Stop(gomock.Any(), workspace).Return(nil),
+				)
+			}
+
+			w := &Watchdog{
+				enabled: true,
+				sw:      mockStore,
+				ww:      mockWorkspace,
+			}
+
+			w.restart(context.Background(), userName)
+		})
+	}
+}
+
+func TestWatchdogMemoryMonitorStop(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_store.NewMockIWorkspace(ctrl)
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+	userName := values.UserName("test")
+	workspace := domain.NewWorkspace("container_id", "user-test", userName)
+	workspace.Status = values.StatusUp
+
+	w := &Watchdog{
+		enabled: true,
+		sw:      mockStore,
+		ww:      mockWorkspace,
+	}
+
+	mm := newMemoryMonitor(mockWorkspace, 0, 0.95)
+	mm.interval = time.Millisecond
+
+	mockStore.EXPECT().Get(gomock.Any(), userName).Return(workspace, nil)
+	mockWorkspace.
+		EXPECT().
+		Stats(gomock.Any(), workspace).
+		Return(values.NewWorkspaceStats(0, 96, 100), nil).
+		MinTimes(1)
+
+	// Start is not expected: the watchdog must not restart the workspace stopped by the monitor
+	stopped := make(chan struct{})
+	mockWorkspace.
+		EXPECT().
+		Stop(gomock.Any(), workspace).
+		DoAndReturn(func(ctx context.Context, ws *domain.Workspace) error {
+			ws.SetStopping(true)
+
+			// the die event is handled before the stop returns, while the workspace is still up with connections
+			w.restart(ctx, userName)
+
+			ws.Status = values.StatusDown
+			close(stopped)
+
+			return nil
+		})
+
+	connection := domain.NewConnection(false, values.NewConnectionIO(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}, nil))
+	err := workspace.AddConnection()
+	if err != nil {
+		t.Fatalf("failed to add connection: %s", err)
+	}
+	mm.Add(workspace, connection)
+	defer mm.Remove(workspace, connection)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("workspace is not stopped")
+	}
+}
+
+func TestWatchdogRun(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_store.NewMockIWorkspace(ctrl)
+	mockWorkspace := mock_workspace.NewMockIWorkspace(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userCh := make(chan values.UserName)
+	errCh := make(chan error, 1)
+	mockWorkspace.EXPECT().WatchDied(gomock.Any()).Return((<-chan values.UserName)(userCh), (<-chan error)(errCh))
+	mockStore.EXPECT().Get(gomock.Any(), values.UserName("test")).Return(nil, store.ErrWorkspaceNotFound)
+
+	w := &Watchdog{
+		enabled: true,
+		sw:      mockStore,
+		ww:      mockWorkspace,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.run(ctx)
+	}()
+
+	userCh <- "test"
+	errCh <- errors.New("stream error")
+	close(userCh)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not stop")
+	}
+}
@@ -52,9 +52,13 @@ func NewSSH(user service.IUser, pipe service.IPipe) *SSH {
 				log.Printf("panic: %+v\n", err)
 			}
 		}()
-		_, winCh, isTty := s.Pty()
+		pty, winCh, isTty := s.Pty()
 		tty := values.NewConnectionIO(s, s, s, s.Close)
 		connection := domain.NewConnection(isTty, tty)
+		if isTty {
+			connection.SetInitialWindow(values.NewWindow(uint(pty.Window.Height), uint(pty.Window.Width)))
+			connection.SetTerm(pty.Term)
+		}
 		newWinCh := connection.WindowSender()
 		defer close(newWinCh)
 		if isTty {
@@ -0,0 +1,86 @@
+package gomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+)
+
+type Metadata struct {
+	syncMap sync.Map
+}
+
+func NewMetadata() *Metadata {
+	return &Metadata{
+		syncMap: sync.Map{},
+	}
+}
+
+// Set replaces the metadata of the user with a copy of metadata.
+func (m *Metadata) Set(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error {
+	m.syncMap.Store(userName, copyMetadata(metadata))
+
+	return nil
+}
+
+func (m *Metadata) Get(ctx context.Context, userName values.UserName) (domain.ContainerMetadata, error) {
+	iMetadata, ok := m.syncMap.Load(userName)
+	if !ok {
+		return nil, store.ErrMetadataNotFound
+	}
+
+	metadata, ok := iMetadata.(domain.ContainerMetadata)
+	if !ok {
+		return nil, errors.New("metadata is broken")
+	}
+
+	return copyMetadata(metadata), nil
+}
+
+func (m *Metadata) Delete(ctx context.Context, userName values.UserName) error {
+	m.syncMap.Delete(userName)
+
+	return nil
+}
+
+func (m *Metadata) List(ctx context.Context) (map[values.UserName]domain.ContainerMetadata, error) {
+	metadataMap := map[values.UserName]domain.ContainerMetadata{}
+
+	var err error
+	m.syncMap.Range(func(key, value interface{}) bool {
+		userName, ok := key.(values.UserName)
+		if !ok {
+			err = errors.New("user name is broken")
+			return false
+		}
+
+		metadata, ok := value.(domain.ContainerMetadata)
+		if !ok {
+			err = errors.New("metadata is broken")
+			return false
+		}
+
+		metadataMap[userName] = copyMetadata(metadata)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metadataMap, nil
+}
+
+// copyMetadata copies metadata so that callers can not modify the stored one.
+func copyMetadata(metadata domain.ContainerMetadata) domain.ContainerMetadata {
+	copied := make(domain.ContainerMetadata, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+
+	return copied
+}
@@ -0,0 +1,54 @@
+package gomap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := NewMetadata()
+
+	testUserName, err := values.NewUserName("testUser")
+	if err != nil {
+		t.Errorf("Error creating test user name: %s", err)
+	}
+
+	_, err = m.Get(ctx, testUserName)
+	if !errors.Is(err, store.ErrMetadataNotFound) {
+		t.Errorf("expected error %+v, got %+v", store.ErrMetadataNotFound, err)
+	}
+
+	metadata := domain.ContainerMetadata{"course": "cs101"}
+	err = m.Set(ctx, testUserName, metadata)
+	assert.NoError(t, err)
+
+	// the stored metadata is not changed by the caller
+	metadata["course"] = "cs102"
+
+	actual, err := m.Get(ctx, testUserName)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ContainerMetadata{"course": "cs101"}, actual)
+
+	metadataMap, err := m.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[values.UserName]domain.ContainerMetadata{
+		testUserName: {"course": "cs101"},
+	}, metadataMap)
+
+	err = m.Delete(ctx, testUserName)
+	assert.NoError(t, err)
+
+	_, err = m.Get(ctx, testUserName)
+	if !errors.Is(err, store.ErrMetadataNotFound) {
+		t.Errorf("expected error %+v, got %+v", store.ErrMetadataNotFound, err)
+	}
+}
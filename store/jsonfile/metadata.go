@@ -0,0 +1,150 @@
+package jsonfile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+)
+
+// Metadata metadata store persisted to a JSON file.
+// The whole file is rewritten on every change, so it is meant for a small number of users.
+type Metadata struct {
+	path        string
+	locker      sync.RWMutex
+	metadataMap map[values.UserName]domain.ContainerMetadata
+}
+
+// NewMetadata loads the metadata from the file at path. A missing file is treated as empty.
+func NewMetadata(path string) (*Metadata, error) {
+	metadataMap := map[values.UserName]domain.ContainerMetadata{}
+
+	buf, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(buf, &metadataMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metadata file: %w", err)
+		}
+	}
+
+	return &Metadata{
+		path:        path,
+		metadataMap: metadataMap,
+	}, nil
+}
+
+// Set replaces the metadata of the user with a copy of metadata.
+func (m *Metadata) Set(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	prev, ok := m.metadataMap[userName]
+	m.metadataMap[userName] = copyMetadata(metadata)
+
+	err := m.save()
+	if err != nil {
+		if ok {
+			m.metadataMap[userName] = prev
+		} else {
+			delete(m.metadataMap, userName)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (m *Metadata) Get(ctx context.Context, userName values.UserName) (domain.ContainerMetadata, error) {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	metadata, ok := m.metadataMap[userName]
+	if !ok {
+		return nil, store.ErrMetadataNotFound
+	}
+
+	return copyMetadata(metadata), nil
+}
+
+func (m *Metadata) Delete(ctx context.Context, userName values.UserName) error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+
+	prev, ok := m.metadataMap[userName]
+	if !ok {
+		return nil
+	}
+	delete(m.metadataMap, userName)
+
+	err := m.save()
+	if err != nil {
+		m.metadataMap[userName] = prev
+		return err
+	}
+
+	return nil
+}
+
+func (m *Metadata) List(ctx context.Context) (map[values.UserName]domain.ContainerMetadata, error) {
+	m.locker.RLock()
+	defer m.locker.RUnlock()
+
+	metadataMap := make(map[values.UserName]domain.ContainerMetadata, len(m.metadataMap))
+	for userName, metadata := range m.metadataMap {
+		metadataMap[userName] = copyMetadata(metadata)
+	}
+
+	return metadataMap, nil
+}
+
+// save writes the metadata to a temporary file and renames it so that the file is not broken by a crash while writing.
+func (m *Metadata) save() error {
+	buf, err := json.Marshal(m.metadataMap)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary metadata file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(buf)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close metadata file: %w", err)
+	}
+
+	err = os.Rename(f.Name(), m.path)
+	if err != nil {
+		return fmt.Errorf("failed to rename metadata file: %w", err)
+	}
+
+	return nil
+}
+
+func copyMetadata(metadata domain.ContainerMetadata) domain.ContainerMetadata {
+	copied := make(domain.ContainerMetadata, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+
+	return copied
+}
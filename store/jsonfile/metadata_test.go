@@ -0,0 +1,84 @@
+package jsonfile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metadata.json")
+
+	m, err := NewMetadata(path)
+	if err != nil {
+		t.Fatalf("failed to create metadata store: %s", err)
+	}
+
+	testUserName, err := values.NewUserName("testUser")
+	if err != nil {
+		t.Fatalf("Error creating test user name: %s", err)
+	}
+
+	_, err = m.Get(ctx, testUserName)
+	if !errors.Is(err, store.ErrMetadataNotFound) {
+		t.Errorf("expected error %+v, got %+v", store.ErrMetadataNotFound, err)
+	}
+
+	metadata := domain.ContainerMetadata{"course": "cs101"}
+	err = m.Set(ctx, testUserName, metadata)
+	assert.NoError(t, err)
+
+	// the stored metadata is not changed by the caller
+	metadata["course"] = "cs102"
+
+	// the metadata is persisted to the file
+	reloaded, err := NewMetadata(path)
+	if err != nil {
+		t.Fatalf("failed to reload metadata store: %s", err)
+	}
+
+	actual, err := reloaded.Get(ctx, testUserName)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ContainerMetadata{"course": "cs101"}, actual)
+
+	metadataMap, err := reloaded.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[values.UserName]domain.ContainerMetadata{
+		testUserName: {"course": "cs101"},
+	}, metadataMap)
+
+	err = reloaded.Delete(ctx, testUserName)
+	assert.NoError(t, err)
+
+	reloaded, err = NewMetadata(path)
+	if err != nil {
+		t.Fatalf("failed to reload metadata store: %s", err)
+	}
+
+	metadataMap, err = reloaded.List(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, metadataMap)
+}
+
+func TestNewMetadataBrokenFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	err := os.WriteFile(path, []byte("{"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write metadata file: %s", err)
+	}
+
+	_, err = NewMetadata(path)
+	assert.Error(t, err)
+}
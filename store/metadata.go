@@ -0,0 +1,22 @@
+//go:generate mockgen -source=$GOFILE -destination=mock_$GOPACKAGE/mock_$GOFILE
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+var (
+	// ErrMetadataNotFound metadata of the user is not found.
+	ErrMetadataNotFound = errors.New("metadata not found")
+)
+
+type IMetadata interface {
+	Set(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error
+	Get(ctx context.Context, userName values.UserName) (domain.ContainerMetadata, error)
+	Delete(ctx context.Context, userName values.UserName) error
+	List(ctx context.Context) (map[values.UserName]domain.ContainerMetadata, error)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metadata.go
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/mazrean/separated-webshell/domain"
+	values "github.com/mazrean/separated-webshell/domain/values"
+)
+
+// MockIMetadata is a mock of IMetadata interface.
+type MockIMetadata struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMetadataMockRecorder
+}
+
+// MockIMetadataMockRecorder is the mock recorder for MockIMetadata.
+type MockIMetadataMockRecorder struct {
+	mock *MockIMetadata
+}
+
+// NewMockIMetadata creates a new mock instance.
+func NewMockIMetadata(ctrl *gomock.Controller) *MockIMetadata {
+	mock := &MockIMetadata{ctrl: ctrl}
+	mock.recorder = &MockIMetadataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMetadata) EXPECT() *MockIMetadataMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockIMetadata) Delete(ctx context.Context, userName values.UserName) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIMetadataMockRecorder) Delete(ctx, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIMetadata)(nil).Delete), ctx, userName)
+}
+
+// Get mocks base method.
+func (m *MockIMetadata) Get(ctx context.Context, userName values.UserName) (domain.ContainerMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userName)
+	ret0, _ := ret[0].(domain.ContainerMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIMetadataMockRecorder) Get(ctx, userName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIMetadata)(nil).Get), ctx, userName)
+}
+
+// List mocks base method.
+func (m *MockIMetadata) List(ctx context.Context) (map[values.UserName]domain.ContainerMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].(map[values.UserName]domain.ContainerMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIMetadataMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIMetadata)(nil).List), ctx)
+}
+
+// Set mocks base method.
+func (m *MockIMetadata) Set(ctx context.Context, userName values.UserName, metadata domain.ContainerMetadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, userName, metadata)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockIMetadataMockRecorder) Set(ctx, userName, metadata interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockIMetadata)(nil).Set), ctx, userName, metadata)
+}
@@ -0,0 +1,45 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+const dockerModulePath = "github.com/docker/docker"
+
+// set by -ldflags "-X github.com/mazrean/separated-webshell/version.Version=..."
+var (
+	// Version release version
+	Version = "dev"
+	// Revision git commit of the build
+	Revision = "unknown"
+	// BuildTime time of the build
+	BuildTime = "unknown"
+)
+
+// GoVersion version of the go toolchain used for the build.
+func GoVersion() string {
+	return runtime.Version()
+}
+
+// DockerSDKVersion version of the docker client module linked into the binary.
+func DockerSDKVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != dockerModulePath {
+			continue
+		}
+
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+
+		return dep.Version
+	}
+
+	return "unknown"
+}
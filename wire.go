@@ -3,6 +3,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/google/wire"
 	"github.com/mazrean/separated-webshell/api"
 	"github.com/mazrean/separated-webshell/repository"
@@ -11,6 +13,7 @@ import (
 	"github.com/mazrean/separated-webshell/ssh"
 	"github.com/mazrean/separated-webshell/store"
 	"github.com/mazrean/separated-webshell/store/gomap"
+	"github.com/mazrean/separated-webshell/store/jsonfile"
 	"github.com/mazrean/separated-webshell/workspace"
 	"github.com/mazrean/separated-webshell/workspace/docker"
 )
@@ -29,28 +32,51 @@ type Server struct {
 	*service.Setup
 	*api.API
 	*ssh.SSH
+	*service.Watchdog
 }
 
-func NewServer(setup *service.Setup, a *api.API, s *ssh.SSH) (*Server, error) {
+func NewServer(setup *service.Setup, a *api.API, s *ssh.SSH, w *service.Watchdog) (*Server, error) {
 	return &Server{
-		Setup: setup,
-		API:   a,
-		SSH:   s,
+		Setup:    setup,
+		API:      a,
+		SSH:      s,
+		Watchdog: w,
 	}, nil
 }
 
+// NewMetadata persists the metadata to METADATA_FILE so that it survives restarts of the server, or keeps it in memory if empty.
+func NewMetadata() (store.IMetadata, error) {
+	path := os.Getenv("METADATA_FILE")
+	if len(path) == 0 {
+		return gomap.NewMetadata(), nil
+	}
+
+	m, err := jsonfile.NewMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
 func InjectServer() (*Server, func(), error) {
 	wire.Build(
 		NewServer,
 		api.NewAPI,
 		api.NewUser,
+		api.NewMaintenance,
+		api.NewVersion,
 		gomap.NewWorkspace,
+		NewMetadata,
 		badger.NewDB,
 		badger.NewTransaction,
 		badger.NewUser,
 		service.NewSetup,
 		service.NewUser,
 		service.NewPipe,
+		service.NewMaintenance,
+		service.NewVersion,
+		service.NewWatchdog,
 		ssh.NewSSH,
 		docker.NewWorkspace,
 		docker.NewWorkspaceConnection,
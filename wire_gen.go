@@ -14,8 +14,10 @@ import (
 	"github.com/mazrean/separated-webshell/ssh"
 	"github.com/mazrean/separated-webshell/store"
 	"github.com/mazrean/separated-webshell/store/gomap"
+	"github.com/mazrean/separated-webshell/store/jsonfile"
 	"github.com/mazrean/separated-webshell/workspace"
 	"github.com/mazrean/separated-webshell/workspace/docker"
+	"os"
 )
 
 // Injectors from wire.go:
@@ -33,13 +35,31 @@ func InjectServer() (*Server, func(), error) {
 	transaction := badger.NewTransaction(db)
 	user := badger.NewUser(db)
 	setup := service.NewSetup(workspace, gomapWorkspace, transaction, user)
-	serviceUser := service.NewUser(workspace, gomapWorkspace, user, transaction)
+	iMetadata, err := NewMetadata()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	maintenance := service.NewMaintenance()
+	serviceUser := service.NewUser(workspace, gomapWorkspace, iMetadata, user, transaction, maintenance)
 	apiUser := api.NewUser(serviceUser)
-	apiAPI := api.NewAPI(apiUser)
+	apiMaintenance := api.NewMaintenance(maintenance)
+	version := service.NewVersion(workspace)
+	apiVersion := api.NewVersion(version)
+	apiAPI := api.NewAPI(apiUser, apiMaintenance, apiVersion)
 	workspaceConnection := docker.NewWorkspaceConnection()
-	pipe := service.NewPipe(gomapWorkspace, workspaceConnection, workspace)
+	pipe, err := service.NewPipe(gomapWorkspace, workspaceConnection, workspace, maintenance)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	sshSSH := ssh.NewSSH(serviceUser, pipe)
-	server, err := NewServer(setup, apiAPI, sshSSH)
+	watchdog, err := service.NewWatchdog(gomapWorkspace, workspace)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	server, err := NewServer(setup, apiAPI, sshSSH, watchdog)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
@@ -65,12 +85,29 @@ type Server struct {
 	*service.Setup
 	*api.API
 	*ssh.SSH
+	*service.Watchdog
 }
 
-func NewServer(setup *service.Setup, a *api.API, s *ssh.SSH) (*Server, error) {
+func NewServer(setup *service.Setup, a *api.API, s *ssh.SSH, w *service.Watchdog) (*Server, error) {
 	return &Server{
-		Setup: setup,
-		API:   a,
-		SSH:   s,
+		Setup:    setup,
+		API:      a,
+		SSH:      s,
+		Watchdog: w,
 	}, nil
 }
+
+// NewMetadata persists the metadata to METADATA_FILE so that it survives restarts of the server, or keeps it in memory if empty.
+func NewMetadata() (store.IMetadata, error) {
+	path := os.Getenv("METADATA_FILE")
+	if len(path) == 0 {
+		return gomap.NewMetadata(), nil
+	}
+
+	m, err := jsonfile.NewMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,188 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// Capacity resources of the docker host seen by the admission controller.
+type Capacity struct {
+	// MemoryTotal total memory of the host in bytes
+	MemoryTotal int64
+	// MemoryReserved sum of the memory limits of running user containers in bytes
+	MemoryReserved int64
+	// CPUs number of CPUs of the host
+	CPUs int
+	// RunningWorkspaces number of running user containers
+	RunningWorkspaces int
+}
+
+// MemoryFree memory not reserved by running user containers in bytes.
+func (c *Capacity) MemoryFree() int64 {
+	return c.MemoryTotal - c.MemoryReserved
+}
+
+// Admission decision of the admission controller.
+type Admission struct {
+	Allow bool
+	// Reason why the workspace is not admitted
+	Reason string
+	// CPULimit overrides the cpu limit of the workspace in nano CPUs if not 0
+	CPULimit int64
+	// MemoryLimit overrides the memory limit of the workspace in bytes if not 0
+	MemoryLimit int64
+}
+
+// AdmissionController decides whether a workspace can be started on the current host load.
+type AdmissionController interface {
+	Admit(ctx context.Context, capacity *Capacity) (*Admission, error)
+}
+
+// admissionController nil admits all workspaces
+var admissionController AdmissionController
+
+// SetAdmissionController replaces the admission controller consulted before starting workspaces.
+func SetAdmissionController(ac AdmissionController) {
+	admissionController = ac
+}
+
+// MemoryAdmissionController rejects workspaces when the free memory of the host is below MinFreeMemory.
+type MemoryAdmissionController struct {
+	// MinFreeMemory in bytes
+	MinFreeMemory int64
+}
+
+func (mac *MemoryAdmissionController) Admit(ctx context.Context, capacity *Capacity) (*Admission, error) {
+	if capacity.MemoryFree() < mac.MinFreeMemory {
+		return &Admission{
+			Allow:  false,
+			Reason: fmt.Sprintf("free memory %d bytes is below %d bytes", capacity.MemoryFree(), mac.MinFreeMemory),
+		}, nil
+	}
+
+	return &Admission{
+		Allow: true,
+	}, nil
+}
+
+// hostCapacity collects the capacity from the docker daemon.
+// The memory limits of running user containers are taken from the memory guard, which inspects each container once.
+func hostCapacity(ctx context.Context) (*Capacity, error) {
+	opCtx, cancel := operationContext(ctx, "Info")
+	info, err := cli.Info(opCtx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ContainerList")
+	ctns, err := cli.ContainerList(opCtx, types.ContainerListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("name", "^/"+regexp.QuoteMeta(containerNamePrefix)),
+			filters.Arg("status", "running"),
+		),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	limits, err := memoryAllocation.Limits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory limits: %w", err)
+	}
+
+	var memoryReserved int64
+	for _, ctn := range ctns {
+		limit := memoryLimit
+		for _, ctnName := range ctn.Names {
+			if ctnLimit, ok := limits[strings.TrimPrefix(ctnName, "/")]; ok {
+				limit = ctnLimit
+				break
+			}
+		}
+
+		memoryReserved += limit
+	}
+
+	return &Capacity{
+		MemoryTotal:       info.MemTotal,
+		MemoryReserved:    memoryReserved,
+		CPUs:              info.NCPU,
+		RunningWorkspaces: len(ctns),
+	}, nil
+}
+
+// admit rejects workspaces while the file descriptors are exhausted, and consults the admission controller and applies the limit overrides to the container.
+func admit(ctx context.Context, containerID string, ctnName string) error {
+	err := checkResourceExhausted()
+	if err != nil {
+		return err
+	}
+
+	if admissionController == nil {
+		return nil
+	}
+
+	capacity, err := hostCapacity(ctx)
+	if err != nil {
+		return err
+	}
+
+	admission, err := admissionController.Admit(ctx, capacity)
+	if err != nil {
+		return fmt.Errorf("failed to admit workspace: %w", err)
+	}
+	if !admission.Allow {
+		return fmt.Errorf("%w: %s", workspace.ErrAdmissionDenied, admission.Reason)
+	}
+
+	// the limits are always reset so that the overrides of the previous start do not remain
+	resources := container.Resources{
+		NanoCPUs: cpuLimit,
+		Memory:   memoryLimit,
+	}
+	if admission.CPULimit != 0 {
+		resources.NanoCPUs = admission.CPULimit
+	}
+	if admission.MemoryLimit != 0 {
+		resources.Memory = admission.MemoryLimit
+	}
+	if resources.Memory > 0 {
+		// same as the default swap limit on create, which must not be below the memory limit
+		resources.MemorySwap = resources.Memory * 2
+	}
+
+	ctnInfo, err := inspectContainer(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if ctnInfo.HostConfig != nil &&
+		ctnInfo.HostConfig.NanoCPUs == resources.NanoCPUs &&
+		ctnInfo.HostConfig.Memory == resources.Memory &&
+		ctnInfo.HostConfig.MemorySwap == resources.MemorySwap {
+		return nil
+	}
+
+	ctx, cancel := operationContext(ctx, "ContainerUpdate")
+	defer cancel()
+
+	_, err = cli.ContainerUpdate(ctx, containerID, container.UpdateConfig{
+		Resources: resources,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update container resources: %w", err)
+	}
+
+	// the memory guard counts the overridden limit, not the default one of the creation
+	memoryAllocation.Update(ctnName, resources.Memory)
+
+	return nil
+}
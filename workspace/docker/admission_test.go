@@ -0,0 +1,183 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedAdmissionController struct {
+	admission *Admission
+}
+
+func (fac *fixedAdmissionController) Admit(ctx context.Context, capacity *Capacity) (*Admission, error) {
+	return fac.admission, nil
+}
+
+func TestAdmit(t *testing.T) {
+	tests := []struct {
+		description string
+		controller  AdmissionController
+		// current the resources of the container before the start
+		current   container.Resources
+		isUpdated bool
+		resources container.Resources
+		isErr     bool
+		err       error
+	}{
+		{
+			description: "no admission controller",
+			controller:  nil,
+		},
+		{
+			description: "enough free memory",
+			controller:  &MemoryAdmissionController{MinFreeMemory: 1e9},
+			isUpdated:   true,
+			resources: container.Resources{
+				NanoCPUs:   5e8,
+				Memory:     1e9,
+				MemorySwap: 2e9,
+			},
+		},
+		{
+			description: "unchanged limits",
+			controller:  &MemoryAdmissionController{MinFreeMemory: 1e9},
+			current: container.Resources{
+				NanoCPUs:   5e8,
+				Memory:     1e9,
+				MemorySwap: 2e9,
+			},
+		},
+		{
+			description: "limits overridden by the previous start",
+			controller:  &MemoryAdmissionController{MinFreeMemory: 1e9},
+			current: container.Resources{
+				NanoCPUs:   2e8,
+				Memory:     5e8,
+				MemorySwap: 1e9,
+			},
+			isUpdated: true,
+			resources: container.Resources{
+				NanoCPUs:   5e8,
+				Memory:     1e9,
+				MemorySwap: 2e9,
+			},
+		},
+		{
+			// the running containers reserve 2.5GB of 4GB
+			description: "not enough free memory",
+			controller:  &MemoryAdmissionController{MinFreeMemory: 2e9},
+			isErr:       true,
+			err:         workspace.ErrAdmissionDenied,
+		},
+		{
+			description: "limit overrides",
+			controller: &fixedAdmissionController{
+				admission: &Admission{
+					Allow:       true,
+					CPULimit:    2e8,
+					MemoryLimit: 5e8,
+				},
+			},
+			isUpdated: true,
+			resources: container.Resources{
+				NanoCPUs:   2e8,
+				Memory:     5e8,
+				MemorySwap: 1e9,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var updateConfig *container.UpdateConfig
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/info"):
+					writeJSON(t, w, types.Info{
+						MemTotal: 4e9,
+						NCPU:     4,
+					})
+				case strings.HasSuffix(r.URL.Path, "/containers/json"):
+					writeJSON(t, w, []types.Container{
+						{ID: "a", Names: []string{"/user-a"}},
+						{ID: "b", Names: []string{"/user-b"}},
+					})
+				case strings.HasSuffix(r.URL.Path, "/containers/a/json"):
+					writeJSON(t, w, admissionTestContainer("user-a", container.Resources{Memory: 2e9}))
+				case strings.HasSuffix(r.URL.Path, "/containers/b/json"):
+					writeJSON(t, w, admissionTestContainer("user-b", container.Resources{Memory: 5e8}))
+				case strings.HasSuffix(r.URL.Path, "/containers/container_id/json"):
+					writeJSON(t, w, admissionTestContainer("user-test", test.current))
+				case strings.HasSuffix(r.URL.Path, "/containers/container_id/update"):
+					updateConfig = &container.UpdateConfig{}
+					err := json.NewDecoder(r.Body).Decode(updateConfig)
+					if err != nil {
+						t.Errorf("failed to decode update config: %s", err)
+					}
+					writeJSON(t, w, container.ContainerUpdateOKBody{})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			defaultController, defaultCPULimit, defaultMemoryLimit, defaultMemoryAllocation := admissionController, cpuLimit, memoryLimit, memoryAllocation
+			admissionController, cpuLimit, memoryLimit, memoryAllocation = test.controller, 5e8, 1e9, &memoryAllocator{}
+			defer func() {
+				admissionController, cpuLimit, memoryLimit, memoryAllocation = defaultController, defaultCPULimit, defaultMemoryLimit, defaultMemoryAllocation
+			}()
+
+			err := admit(context.Background(), "container_id", "user-test")
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				assert.Nil(t, updateConfig)
+				return
+			}
+
+			assert.NoError(t, err)
+			if !test.isUpdated {
+				assert.Nil(t, updateConfig)
+				return
+			}
+
+			if assert.NotNil(t, updateConfig) {
+				assert.Equal(t, test.resources, updateConfig.Resources)
+			}
+		})
+	}
+}
+
+func TestHostCapacity(t *testing.T) {
+	setupMemoryGuardTest(t, 0, map[string]int64{"user-a": 2e9, "user-b": 5e8})
+
+	capacity, err := hostCapacity(context.Background())
+	assert.NoError(t, err)
+
+	// the actual limits, not 2 * the default limit
+	assert.Equal(t, &Capacity{
+		MemoryTotal:       4e9,
+		MemoryReserved:    2.5e9,
+		RunningWorkspaces: 2,
+	}, capacity)
+}
+
+func admissionTestContainer(ctnName string, resources container.Resources) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			Name: "/" + ctnName,
+			HostConfig: &container.HostConfig{
+				Resources: resources,
+			},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/docker/docker/api/types/versions"
+)
+
+// minimum api versions of the optional container options
+const (
+	runtimeAPIVersion     = "1.25"
+	nanoCPUsAPIVersion    = "1.25"
+	stopTimeoutAPIVersion = "1.25"
+)
+
+// stopTimeoutSupported whether the daemon accepts the stop timeout on container creation
+var stopTimeoutSupported = true
+
+// checkAPIVersion negotiates the api version with the daemon and disables the options the daemon does not support.
+// CONTAINER_RUNTIME is not disabled since running user containers without the sandbox runtime is unsafe.
+func checkAPIVersion(ctx context.Context) error {
+	opCtx, cancel := operationContext(ctx, "Ping")
+	cli.NegotiateAPIVersion(opCtx)
+	cancel()
+	apiVersion := cli.ClientVersion()
+
+	if len(containerRuntime) != 0 && versions.LessThan(apiVersion, runtimeAPIVersion) {
+		return fmt.Errorf("CONTAINER_RUNTIME requires docker api %s or later(negotiated: %s)", runtimeAPIVersion, apiVersion)
+	}
+
+	if cpuLimit != 0 && versions.LessThan(apiVersion, nanoCPUsAPIVersion) {
+		log.Printf("CPU_LIMIT requires docker api %s or later(negotiated: %s), it is ignored\n", nanoCPUsAPIVersion, apiVersion)
+		cpuLimit = 0
+	}
+
+	stopTimeoutSupported = !versions.LessThan(apiVersion, stopTimeoutAPIVersion)
+	if !stopTimeoutSupported {
+		log.Printf("STOP_TIMEOUT on container creation requires docker api %s or later(negotiated: %s), the daemon default is used for stops not requested by the server\n", stopTimeoutAPIVersion, apiVersion)
+	}
+
+	return nil
+}
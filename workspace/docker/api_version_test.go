@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAPIVersion(t *testing.T) {
+	tests := []struct {
+		description          string
+		apiVersion           string
+		runtime              string
+		cpuLimited           bool
+		stopTimeoutSupported bool
+		isErr                bool
+	}{
+		{
+			description:          "supported daemon",
+			apiVersion:           "1.41",
+			runtime:              "runsc",
+			cpuLimited:           true,
+			stopTimeoutSupported: true,
+		},
+		{
+			description:          "old daemon",
+			apiVersion:           "1.24",
+			cpuLimited:           false,
+			stopTimeoutSupported: false,
+		},
+		{
+			description: "old daemon with runtime",
+			apiVersion:  "1.24",
+			runtime:     "runsc",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/_ping") {
+					http.NotFound(w, r)
+					return
+				}
+
+				w.Header().Set("API-Version", test.apiVersion)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			defaultRuntime := containerRuntime
+			defaultCPULimit := cpuLimit
+			defer func() {
+				containerRuntime = defaultRuntime
+				cpuLimit = defaultCPULimit
+				stopTimeoutSupported = true
+			}()
+			containerRuntime = test.runtime
+			cpuLimit = 500000000
+
+			err := checkAPIVersion(context.Background())
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.cpuLimited, cpuLimit != 0)
+			assert.Equal(t, test.stopTimeoutSupported, stopTimeoutSupported)
+		})
+	}
+}
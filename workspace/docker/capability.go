@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// capabilityBlocklist capabilities that should not be effective in user containers
+var capabilityBlocklist = os.Getenv("CAPABILITY_BLOCKLIST")
+
+// InspectCapabilities runs `capsh --print` as root in the container of the user and returns the capabilities of the container.
+// The image must contain capsh.
+func (w *Workspace) InspectCapabilities(ctx context.Context, userName values.UserName) (*values.CapabilitySet, error) {
+	output, err := execOutput(ctx, containerName(userName), rootUser, []string{"capsh", "--print"})
+	if err != nil {
+		return nil, err
+	}
+
+	capabilitySet, err := parseCapsh(output)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := blockedCapabilities(capabilitySet.Effective(), capabilityBlocklist)
+	if len(blocked) != 0 {
+		log.Printf("SECURITY WARNING: blocklisted capabilities are effective in %s: %s\n", containerName(userName), strings.Join(blocked, ","))
+	}
+
+	return capabilitySet, nil
+}
+
+// parseCapsh parses the `Current:` and `Bounding set` lines of `capsh --print`.
+func parseCapsh(output string) (*values.CapabilitySet, error) {
+	var (
+		current                 string
+		bounding                []string
+		hasCurrent, hasBounding bool
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Current:"):
+			current = strings.TrimSpace(strings.TrimPrefix(line, "Current:"))
+			hasCurrent = true
+		case strings.HasPrefix(line, "Bounding set"):
+			strBounding := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "Bounding set"), " ="))
+			strBounding = strings.TrimPrefix(strBounding, "=")
+			bounding = splitCapabilities(strBounding)
+			hasBounding = true
+		}
+	}
+	if !hasCurrent || !hasBounding {
+		return nil, fmt.Errorf("unexpected capsh output: %q", output)
+	}
+
+	effective, permitted, err := parseCapabilityText(current, bounding)
+	if err != nil {
+		return nil, err
+	}
+
+	return values.NewCapabilitySet(effective, permitted, bounding), nil
+}
+
+// parseCapabilityText parses the textual representation of cap_to_text(3), e.g. `cap_chown,cap_kill=ep cap_net_raw+p`.
+// A clause without capability names applies to all capabilities, which are regarded as the bounding set.
+func parseCapabilityText(text string, all []string) ([]string, []string, error) {
+	effective, permitted := map[string]bool{}, map[string]bool{}
+
+	for _, clause := range strings.Fields(text) {
+		opIndex := strings.IndexAny(clause, "=+-")
+		if opIndex < 0 {
+			return nil, nil, fmt.Errorf("invalid capability clause: %s", clause)
+		}
+
+		names := splitCapabilities(clause[:opIndex])
+		if len(names) == 0 {
+			names = all
+		}
+
+		actions := clause[opIndex:]
+		for len(actions) != 0 {
+			op := actions[0]
+			flagEnd := strings.IndexAny(actions[1:], "=+-")
+			if flagEnd < 0 {
+				flagEnd = len(actions) - 1
+			}
+			flags := actions[1 : flagEnd+1]
+			actions = actions[flagEnd+1:]
+
+			for _, name := range names {
+				if op == '=' {
+					delete(effective, name)
+					delete(permitted, name)
+				}
+
+				for _, flag := range flags {
+					var set map[string]bool
+					switch flag {
+					case 'e':
+						set = effective
+					case 'p':
+						set = permitted
+					case 'i':
+						continue
+					default:
+						return nil, nil, fmt.Errorf("invalid capability flag: %c", flag)
+					}
+
+					if op == '-' {
+						delete(set, name)
+					} else {
+						set[name] = true
+					}
+				}
+			}
+		}
+	}
+
+	return sortedKeys(effective), sortedKeys(permitted), nil
+}
+
+func splitCapabilities(str string) []string {
+	capabilities := []string{}
+	for _, capability := range strings.Split(str, ",") {
+		capability = strings.TrimSpace(capability)
+		if len(capability) != 0 {
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	return capabilities
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// blockedCapabilities returns the capabilities in the comma separated blocklist.
+func blockedCapabilities(capabilities []string, blocklist string) []string {
+	blockedSet := map[string]bool{}
+	for _, capability := range splitCapabilities(blocklist) {
+		blockedSet[strings.ToLower(capability)] = true
+	}
+
+	blocked := []string{}
+	for _, capability := range capabilities {
+		if blockedSet[strings.ToLower(capability)] {
+			blocked = append(blocked, capability)
+		}
+	}
+
+	return blocked
+}
@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCapsh(t *testing.T) {
+	tests := []struct {
+		description string
+		output      string
+		effective   []string
+		permitted   []string
+		bounding    []string
+		isErr       bool
+	}{
+		{
+			description: "docker default",
+			output: `Current: cap_chown,cap_kill,cap_net_raw=ep
+Bounding set =cap_chown,cap_kill,cap_net_raw
+Securebits: 00/0x0/1'b0
+uid=0(root)
+`,
+			effective: []string{"cap_chown", "cap_kill", "cap_net_raw"},
+			permitted: []string{"cap_chown", "cap_kill", "cap_net_raw"},
+			bounding:  []string{"cap_chown", "cap_kill", "cap_net_raw"},
+		},
+		{
+			description: "all capabilities",
+			output: `Current: =ep
+Bounding set =cap_chown,cap_sys_admin
+`,
+			effective: []string{"cap_chown", "cap_sys_admin"},
+			permitted: []string{"cap_chown", "cap_sys_admin"},
+			bounding:  []string{"cap_chown", "cap_sys_admin"},
+		},
+		{
+			description: "no capabilities",
+			output: `Current: =
+Bounding set =cap_chown
+`,
+			effective: []string{},
+			permitted: []string{},
+			bounding:  []string{"cap_chown"},
+		},
+		{
+			description: "mixed clauses",
+			output: `Current: =ep cap_sys_admin-e cap_net_raw-ep
+Bounding set =cap_chown,cap_net_raw,cap_sys_admin
+`,
+			effective: []string{"cap_chown"},
+			permitted: []string{"cap_chown", "cap_sys_admin"},
+			bounding:  []string{"cap_chown", "cap_net_raw", "cap_sys_admin"},
+		},
+		{
+			description: "unexpected output",
+			output:      "capsh: command not found\n",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			capabilitySet, err := parseCapsh(test.output)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.effective, capabilitySet.Effective())
+			assert.Equal(t, test.permitted, capabilitySet.Permitted())
+			assert.Equal(t, test.bounding, capabilitySet.Bounding())
+		})
+	}
+}
+
+func TestBlockedCapabilities(t *testing.T) {
+	blocked := blockedCapabilities([]string{"cap_chown", "cap_net_raw", "cap_sys_admin"}, "CAP_SYS_ADMIN, cap_net_raw")
+	assert.Equal(t, []string{"cap_net_raw", "cap_sys_admin"}, blocked)
+}
+
+func TestInspectCapabilitiesDeadline(t *testing.T) {
+	// capsh hangs in the container
+	setupTestClient(t, hangingExecHandler(t, containerName("test")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := (&Workspace{}).InspectCapabilities(ctx, "test")
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("capsh is not bounded by the context")
+	}
+}
@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"sync"
+
+	"github.com/mazrean/separated-webshell/domain"
+)
+
+type createCall struct {
+	wg sync.WaitGroup
+	// dups number of callers waiting for the result of the call
+	dups      int
+	workspace *domain.Workspace
+	err       error
+}
+
+// createGroup coalesces concurrent creations of the same container into a single docker call.
+type createGroup struct {
+	locker sync.Mutex
+	calls  map[string]*createCall
+}
+
+// do runs fn once for concurrent calls with the same key and returns the result of fn to all of them.
+func (g *createGroup) do(key string, fn func() (*domain.Workspace, error)) (*domain.Workspace, error) {
+	g.locker.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*createCall{}
+	}
+	if call, ok := g.calls[key]; ok {
+		call.dups++
+		g.locker.Unlock()
+		call.wg.Wait()
+
+		return call.workspace, call.err
+	}
+
+	call := &createCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.locker.Unlock()
+
+	call.workspace, call.err = fn()
+	call.wg.Done()
+
+	g.locker.Lock()
+	delete(g.calls, key)
+	g.locker.Unlock()
+
+	return call.workspace, call.err
+}
+
+var creates = &createGroup{}
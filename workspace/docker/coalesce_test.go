@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateGroup(t *testing.T) {
+	g := &createGroup{}
+
+	callNum := 0
+	release := make(chan struct{})
+	fn := func() (*domain.Workspace, error) {
+		callNum++
+		<-release
+
+		return domain.NewWorkspace("container_id", "user-test", "test"), nil
+	}
+
+	started := make(chan struct{})
+	results := make(chan *domain.Workspace, 3)
+	go func() {
+		ws, err := g.do("user-test", func() (*domain.Workspace, error) {
+			close(started)
+			return fn()
+		})
+		assert.NoError(t, err)
+		results <- ws
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws, err := g.do("user-test", fn)
+			assert.NoError(t, err)
+			results <- ws
+		}()
+	}
+
+	// wait until the followers join the running call
+	for {
+		g.locker.Lock()
+		dups := g.calls["user-test"].dups
+		g.locker.Unlock()
+		if dups == 2 {
+			break
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	first := <-results
+	assert.Same(t, first, <-results)
+	assert.Same(t, first, <-results)
+	assert.Equal(t, 1, callNum)
+
+	// the next call after completion runs again
+	expectedErr := errors.New("create error")
+	_, err := g.do("user-test", func() (*domain.Workspace, error) {
+		return nil, expectedErr
+	})
+	assert.ErrorIs(t, err, expectedErr)
+}
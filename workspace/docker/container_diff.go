@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// changeDelete kind of a deleted path in the container diff(archive.ChangeDelete)
+const changeDelete uint8 = 2
+
+// CompareContainers compares the changes from the image of the containers of two users.
+// A path changed in both containers is modified if the kinds of the changes or the sizes of the files differ.
+func (w *Workspace) CompareContainers(ctx context.Context, userA values.UserName, userB values.UserName) (*values.ContainerDiff, error) {
+	changesA, err := containerChanges(ctx, containerName(userA))
+	if err != nil {
+		return nil, err
+	}
+
+	changesB, err := containerChanges(ctx, containerName(userB))
+	if err != nil {
+		return nil, err
+	}
+
+	onlyInA := []string{}
+	onlyInB := []string{}
+	modified := []string{}
+	for path, kindA := range changesA {
+		kindB, ok := changesB[path]
+		if !ok {
+			onlyInA = append(onlyInA, path)
+			continue
+		}
+
+		isModified, err := isModifiedPath(ctx, userA, userB, path, kindA, kindB)
+		if err != nil {
+			return nil, err
+		}
+		if isModified {
+			modified = append(modified, path)
+		}
+	}
+	for path := range changesB {
+		if _, ok := changesA[path]; !ok {
+			onlyInB = append(onlyInB, path)
+		}
+	}
+
+	sort.Strings(onlyInA)
+	sort.Strings(onlyInB)
+	sort.Strings(modified)
+
+	return values.NewContainerDiff(onlyInA, onlyInB, modified), nil
+}
+
+// containerChanges returns the kinds of the changes from the image by path.
+func containerChanges(ctx context.Context, ctnName string) (map[string]uint8, error) {
+	ctx, cancel := operationContext(ctx, "ContainerDiff")
+	defer cancel()
+
+	items, err := cli.ContainerDiff(ctx, ctnName)
+	if errdefs.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, ctnName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container diff: %w", err)
+	}
+
+	return changesByPath(items), nil
+}
+
+func changesByPath(items []container.ContainerChangeResponseItem) map[string]uint8 {
+	changes := make(map[string]uint8, len(items))
+	for _, item := range items {
+		changes[item.Path] = item.Kind
+	}
+
+	return changes
+}
+
+func isModifiedPath(ctx context.Context, userA values.UserName, userB values.UserName, path string, kindA uint8, kindB uint8) (bool, error) {
+	if kindA != kindB {
+		return true, nil
+	}
+	if kindA == changeDelete {
+		return false, nil
+	}
+
+	sizeA, err := fileSize(ctx, containerName(userA), path)
+	if err != nil {
+		return false, err
+	}
+
+	sizeB, err := fileSize(ctx, containerName(userB), path)
+	if err != nil {
+		return false, err
+	}
+
+	return sizeA != sizeB, nil
+}
+
+func fileSize(ctx context.Context, ctnName string, path string) (int64, error) {
+	ctx, cancel := operationContext(ctx, "ContainerStatPath")
+	defer cancel()
+
+	stat, err := cli.ContainerStatPath(ctx, ctnName, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s in %s: %w", path, ctnName, err)
+	}
+
+	return stat.Size, nil
+}
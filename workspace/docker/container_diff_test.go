@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareContainers(t *testing.T) {
+	changes := map[string][]container.ContainerChangeResponseItem{
+		"user-a": {
+			{Kind: 0, Path: "/home/ubuntu"},
+			{Kind: 1, Path: "/home/ubuntu/a.txt"},
+			{Kind: 1, Path: "/home/ubuntu/same.txt"},
+			{Kind: 1, Path: "/home/ubuntu/size.txt"},
+			{Kind: 2, Path: "/home/ubuntu/deleted.txt"},
+			{Kind: 0, Path: "/home/ubuntu/kind.txt"},
+		},
+		"user-b": {
+			{Kind: 0, Path: "/home/ubuntu"},
+			{Kind: 1, Path: "/home/ubuntu/b.txt"},
+			{Kind: 1, Path: "/home/ubuntu/same.txt"},
+			{Kind: 1, Path: "/home/ubuntu/size.txt"},
+			{Kind: 2, Path: "/home/ubuntu/deleted.txt"},
+			{Kind: 2, Path: "/home/ubuntu/kind.txt"},
+		},
+	}
+	sizes := map[string]int64{
+		"user-a:/home/ubuntu":          4096,
+		"user-b:/home/ubuntu":          4096,
+		"user-a:/home/ubuntu/same.txt": 10,
+		"user-b:/home/ubuntu/same.txt": 10,
+		"user-a:/home/ubuntu/size.txt": 10,
+		"user-b:/home/ubuntu/size.txt": 20,
+	}
+
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths := strings.Split(r.URL.Path, "/")
+		if len(paths) < 2 {
+			http.NotFound(w, r)
+			return
+		}
+		ctnName := paths[len(paths)-2]
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/changes"):
+			items, ok := changes[ctnName]
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				writeJSON(t, w, errorResponse{Message: "No such container: " + ctnName})
+				return
+			}
+
+			writeJSON(t, w, items)
+		case r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/archive"):
+			size, ok := sizes[ctnName+":"+r.URL.Query().Get("path")]
+			if !ok {
+				t.Errorf("unexpected stat: %s:%s", ctnName, r.URL.Query().Get("path"))
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			stat, err := json.Marshal(types.ContainerPathStat{Size: size})
+			if err != nil {
+				t.Errorf("failed to encode stat: %s", err)
+			}
+			w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(stat))
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	diff, err := (&Workspace{}).CompareContainers(context.Background(), "a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/home/ubuntu/a.txt"}, diff.OnlyInA())
+	assert.Equal(t, []string{"/home/ubuntu/b.txt"}, diff.OnlyInB())
+	assert.Equal(t, []string{"/home/ubuntu/kind.txt", "/home/ubuntu/size.txt"}, diff.Modified())
+
+	_, err = (&Workspace{}).CompareContainers(context.Background(), "a", "c")
+	assert.ErrorIs(t, err, workspace.ErrWorkspaceNotFound)
+}
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// CopyFromContainer returns a tar archive of the file or the directory at path in the container of the user.
+// The caller must close the archive.
+func (w *Workspace) CopyFromContainer(ctx context.Context, userName values.UserName, path string) (io.ReadCloser, error) {
+	// the operation timeout also bounds reading the archive, so it is cancelled when the archive is closed
+	opCtx, cancel := operationContext(ctx, "CopyFromContainer")
+	reader, _, err := cli.CopyFromContainer(opCtx, containerName(userName), path)
+	if err != nil {
+		cancel()
+	}
+	if errdefs.IsNotFound(err) {
+		// the client reports a missing container and a missing path as the same error
+		_, inspectErr := inspectContainer(ctx, containerName(userName))
+		if errdefs.IsNotFound(inspectErr) {
+			return nil, workspace.ErrWorkspaceNotFound
+		}
+
+		return nil, fmt.Errorf("%w: %s", workspace.ErrFileNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from container: %w", err)
+	}
+
+	return &cancelReadCloser{
+		ReadCloser: reader,
+		cancel:     cancel,
+	}, nil
+}
+
+// cancelReadCloser cancels the context of the call that returned the body when the body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (crc *cancelReadCloser) Close() error {
+	defer crc.cancel()
+
+	return crc.ReadCloser.Close()
+}
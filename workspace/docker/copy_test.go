@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyFromContainer(t *testing.T) {
+	tests := []struct {
+		description string
+		statusCode  int
+		message     string
+		noContainer bool
+		err         error
+		isErr       bool
+	}{
+		{
+			description: "copy file",
+			statusCode:  http.StatusOK,
+		},
+		{
+			description: "file not found",
+			statusCode:  http.StatusNotFound,
+			message:     "Could not find the file /home/ubuntu/a.txt in container user-test",
+			err:         workspace.ErrFileNotFound,
+			isErr:       true,
+		},
+		{
+			description: "container not found",
+			statusCode:  http.StatusNotFound,
+			message:     "No such container: user-test",
+			noContainer: true,
+			err:         workspace.ErrWorkspaceNotFound,
+			isErr:       true,
+		},
+		{
+			description: "daemon error",
+			statusCode:  http.StatusInternalServerError,
+			message:     "internal error",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/containers/user-test/json") {
+					if test.noContainer {
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(http.StatusNotFound)
+						writeJSON(t, w, errorResponse{Message: test.message})
+						return
+					}
+
+					writeJSON(t, w, types.ContainerJSON{
+						ContainerJSONBase: &types.ContainerJSONBase{ID: "container_id"},
+					})
+					return
+				}
+
+				if !strings.HasSuffix(r.URL.Path, "/containers/user-test/archive") {
+					http.NotFound(w, r)
+					return
+				}
+				assert.Equal(t, "/home/ubuntu/a.txt", r.URL.Query().Get("path"))
+
+				if test.statusCode != http.StatusOK {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(test.statusCode)
+					writeJSON(t, w, errorResponse{Message: test.message})
+					return
+				}
+
+				w.Header().Set("X-Docker-Container-Path-Stat", "e30=")
+				_, err := io.WriteString(w, "archive")
+				if err != nil {
+					t.Errorf("failed to write archive: %s", err)
+				}
+			}))
+
+			reader, err := (&Workspace{}).CopyFromContainer(context.Background(), "test", "/home/ubuntu/a.txt")
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			defer reader.Close()
+
+			archive, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, "archive", string(archive))
+		})
+	}
+}
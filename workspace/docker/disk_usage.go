@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// diskUsageCacheTTL size inspection walks the writable layer, so the result is cached for this period
+const diskUsageCacheTTL = time.Minute
+
+type diskUsage struct {
+	writableBytes int64
+	rootfsBytes   int64
+	inspectedAt   time.Time
+}
+
+var diskUsageCache sync.Map
+
+// DiskUsage returns the size of the writable layer and the total size of the root filesystem of the container of the user.
+// The result is cached for a minute since the size inspection is expensive.
+func (w *Workspace) DiskUsage(ctx context.Context, userName values.UserName) (int64, int64, error) {
+	if v, ok := diskUsageCache.Load(userName); ok {
+		usage := v.(*diskUsage)
+		if time.Since(usage.inspectedAt) < diskUsageCacheTTL {
+			return usage.writableBytes, usage.rootfsBytes, nil
+		}
+	}
+
+	ctx, cancel := operationContext(ctx, "ContainerInspect")
+	defer cancel()
+
+	ctnInfo, _, err := cli.ContainerInspectWithRaw(ctx, containerName(userName), true)
+	if errdefs.IsNotFound(err) {
+		diskUsageCache.Delete(userName)
+		return 0, 0, workspace.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	usage := &diskUsage{
+		inspectedAt: time.Now(),
+	}
+	if ctnInfo.SizeRw != nil {
+		usage.writableBytes = *ctnInfo.SizeRw
+	}
+	if ctnInfo.SizeRootFs != nil {
+		usage.rootfsBytes = *ctnInfo.SizeRootFs
+	}
+	diskUsageCache.Store(userName, usage)
+
+	return usage.writableBytes, usage.rootfsBytes, nil
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskUsage(t *testing.T) {
+	inspectNum := 0
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/user-test/json"):
+			inspectNum++
+			assert.Equal(t, "1", r.URL.Query().Get("size"))
+
+			sizeRw, sizeRootFs := int64(1024), int64(4096)
+			writeJSON(t, w, types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:         "container_id",
+					SizeRw:     &sizeRw,
+					SizeRootFs: &sizeRootFs,
+				},
+			})
+		case strings.HasSuffix(r.URL.Path, "/containers/user-unknown/json"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, errorResponse{Message: "No such container: user-unknown"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(func() {
+		diskUsageCache.Delete("test")
+	})
+
+	w := &Workspace{}
+
+	for i := 0; i < 2; i++ {
+		writableBytes, rootfsBytes, err := w.DiskUsage(context.Background(), "test")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1024), writableBytes)
+		assert.Equal(t, int64(4096), rootfsBytes)
+	}
+	assert.Equal(t, 1, inspectNum, "size inspection is not cached")
+
+	_, _, err := w.DiskUsage(context.Background(), "unknown")
+	assert.ErrorIs(t, err, workspace.ErrWorkspaceNotFound)
+}
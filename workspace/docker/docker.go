@@ -3,10 +3,11 @@ package docker
 import (
 	"context"
 	"fmt"
-	"io"
+	"log"
+	"math/rand"
 	"os"
+	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 )
 
@@ -15,28 +16,127 @@ var (
 	imageRef     = os.Getenv("IMAGE_NAME")
 	imageUser    = os.Getenv("IMAGE_USER")
 	imageCmd     = os.Getenv("IMAGE_CMD")
-	cli          *client.Client
+	// containerRuntime the daemon default runtime is used when empty
+	containerRuntime = os.Getenv("CONTAINER_RUNTIME")
+	// waitForDaemon maximum time to wait for the docker daemon on setup. empty means no wait.
+	waitForDaemon = os.Getenv("WAIT_FOR_DAEMON")
+	cli           *client.Client
 )
 
+const maxDaemonRetryInterval = 30 * time.Second
+
 func Setup() error {
-	var err error
-	cli, err = client.NewClientWithOpts()
+	err := SetupClient()
 	if err != nil {
-		return fmt.Errorf("failed to create docker client: %w", err)
+		return err
 	}
 
 	ctx := context.Background()
 
-	if len(isLocalImage) == 0 || isLocalImage == "false" {
-		reader, err := cli.ImagePull(ctx, imageRef, types.ImagePullOptions{})
+	if len(waitForDaemon) != 0 {
+		timeout, err := time.ParseDuration(waitForDaemon)
 		if err != nil {
-			return fmt.Errorf("failed to pull image: %w", err)
+			return fmt.Errorf("invalid wait for daemon: %w", err)
 		}
-		_, err = io.Copy(os.Stdout, reader)
+
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err = WaitForDaemon(waitCtx, time.Second)
 		if err != nil {
-			return fmt.Errorf("failed to copy stdout: %w", err)
+			return err
 		}
 	}
 
+	err = checkAPIVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = checkRuntime(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = checkRootless(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = checkStorageDriver(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(isLocalImage) == 0 || isLocalImage == "false" {
+		err = pullImage(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetupClient setup the docker client without pulling the image.
+func SetupClient() error {
+	var err error
+	cli, err = client.NewClientWithOpts(clientOpts()...)
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	return nil
+}
+
+// WaitForDaemon pings the docker daemon until it responds or ctx is done.
+// The interval between pings starts from retryInterval and doubles with jitter up to 30s.
+func WaitForDaemon(ctx context.Context, retryInterval time.Duration) error {
+	interval := retryInterval
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+
+	for {
+		opCtx, cancel := operationContext(ctx, "Ping")
+		_, err := cli.Ping(opCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		jitter := time.Duration(rand.Int63n(int64(interval)/2 + 1))
+		log.Printf("docker daemon is not ready, retrying in %s: %+v\n", interval+jitter, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("docker daemon is not ready: %w", err)
+		case <-time.After(interval + jitter):
+		}
+
+		interval *= 2
+		if interval > maxDaemonRetryInterval {
+			interval = maxDaemonRetryInterval
+		}
+	}
+}
+
+func checkRuntime(ctx context.Context) error {
+	if len(containerRuntime) == 0 {
+		return nil
+	}
+
+	ctx, cancel := operationContext(ctx, "Info")
+	defer cancel()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	if _, ok := info.Runtimes[containerRuntime]; !ok {
+		return fmt.Errorf("runtime %s is not available", containerRuntime)
+	}
+
 	return nil
 }
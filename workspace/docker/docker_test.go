@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTestClient replaces the docker client with one connected to a fake daemon.
+func setupTestClient(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	// tcp rather than http so that hijacked attach requests can dial the server
+	testCli, err := client.NewClientWithOpts(client.WithHost("tcp://" + server.Listener.Addr().String()))
+	if err != nil {
+		t.Fatalf("failed to create test client: %s", err)
+	}
+
+	defaultCli := cli
+	cli = testCli
+	t.Cleanup(func() {
+		cli = defaultCli
+	})
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		t.Errorf("failed to encode response: %s", err)
+	}
+}
+
+func TestCheckRuntime(t *testing.T) {
+	tests := []struct {
+		description string
+		runtime     string
+		isErr       bool
+	}{
+		{
+			description: "default runtime",
+			runtime:     "",
+		},
+		{
+			description: "available runtime",
+			runtime:     "runsc",
+		},
+		{
+			description: "unavailable runtime",
+			runtime:     "kata",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/info") {
+					http.NotFound(w, r)
+					return
+				}
+
+				writeJSON(t, w, types.Info{
+					Runtimes: map[string]types.Runtime{
+						"runc":  {Path: "runc"},
+						"runsc": {Path: "/usr/local/bin/runsc"},
+					},
+				})
+			}))
+
+			defaultRuntime := containerRuntime
+			containerRuntime = test.runtime
+			defer func() {
+				containerRuntime = defaultRuntime
+			}()
+
+			err := checkRuntime(context.Background())
+			if test.isErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWaitForDaemon(t *testing.T) {
+	tests := []struct {
+		description string
+		failureNum  int
+		timeout     time.Duration
+		isErr       bool
+	}{
+		{
+			description: "daemon ready",
+			failureNum:  0,
+			timeout:     time.Second,
+		},
+		{
+			description: "daemon ready after retry",
+			failureNum:  3,
+			timeout:     time.Second,
+		},
+		{
+			description: "daemon not ready",
+			failureNum:  1000,
+			timeout:     50 * time.Millisecond,
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pingNum := 0
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/_ping") {
+					http.NotFound(w, r)
+					return
+				}
+
+				pingNum++
+				if pingNum <= test.failureNum {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+
+			err := WaitForDaemon(ctx, time.Millisecond)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
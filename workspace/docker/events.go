@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// WatchDied sends the users whose containers exited until ctx is done or the event stream fails.
+// It also reports the exits of containers stopped on purpose, so the receiver must tell them apart.
+func (w *Workspace) WatchDied(ctx context.Context) (<-chan values.UserName, <-chan error) {
+	userCh := make(chan values.UserName)
+	errCh := make(chan error, 1)
+
+	// the event stream is not bounded by the operation timeout since it lasts until ctx is done
+	msgCh, eventErrCh := cli.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", "container"),
+			filters.Arg("event", "die"),
+		),
+	})
+
+	go func() {
+		defer close(userCh)
+		// a panic is reported as a failure of the watch so that the receiver watches again
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			log.Printf("panic in docker events watcher: %v\n%s", r, debug.Stack())
+			errCh <- fmt.Errorf("panic in docker events watcher: %v", r)
+		}()
+
+		for {
+			select {
+			case msg := <-msgCh:
+				userName, ok := userNameFromContainerName(msg.Actor.Attributes["name"])
+				if !ok {
+					continue
+				}
+
+				select {
+				case userCh <- userName:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-eventErrCh:
+				if ctx.Err() == nil {
+					errCh <- fmt.Errorf("failed to watch docker events: %w", err)
+				}
+				return
+			}
+		}
+	}()
+
+	return userCh, errCh
+}
@@ -0,0 +1,61 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchDied(t *testing.T) {
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/events") {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Contains(t, r.URL.Query().Get("filters"), "die")
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		for _, name := range []string{"other", "user-test"} {
+			err := encoder.Encode(events.Message{
+				Type:   events.ContainerEventType,
+				Action: "die",
+				Actor: events.Actor{
+					ID:         "container_id",
+					Attributes: map[string]string{"name": name},
+				},
+			})
+			if err != nil {
+				t.Errorf("failed to encode event: %s", err)
+			}
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userCh, errCh := (&Workspace{}).WatchDied(ctx)
+
+	select {
+	case userName := <-userCh:
+		assert.Equal(t, values.UserName("test"), userName)
+	case <-time.After(time.Second):
+		t.Fatal("died user was not sent")
+	}
+
+	// the stream ends when the daemon closes the connection
+	select {
+	case _, ok := <-userCh:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not stop")
+	}
+	assert.Error(t, <-errCh)
+}
@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// envNameExpression names of environment variables allowed in ENV_ALLOWLIST
+var envNameExpression = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var (
+	// cleanEnv if true, sessions do not inherit the environment of the container except envAllowlist
+	cleanEnv bool
+	// envAllowlist names of the container environment variables passed to sessions when cleanEnv is true
+	envAllowlist []string
+	// randomSeed seed of random generators passed to sessions. empty means no seed.
+	randomSeed string
+)
+
+// parseRandomSeed validates the seed. It is limited to the range of PYTHONHASHSEED.
+func parseRandomSeed(strSeed string) (string, error) {
+	seed, err := strconv.ParseUint(strSeed, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid random seed: %w", err)
+	}
+
+	return strconv.FormatUint(seed, 10), nil
+}
+
+// sessionEnv environment variables added to every session.
+// RANDOM_SEED is only a hint for programs that read it and PYTHONHASHSEED only fixes the hash of python,
+// so other sources of randomness are not determinized.
+func sessionEnv() []string {
+	if len(randomSeed) == 0 {
+		return nil
+	}
+
+	return []string{
+		"RANDOM_SEED=" + randomSeed,
+		"PYTHONHASHSEED=" + randomSeed,
+	}
+}
+
+func parseEnvAllowlist(strAllowlist string) ([]string, error) {
+	allowlist := []string{}
+	for _, name := range strings.Split(strAllowlist, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		if !envNameExpression.MatchString(name) {
+			return nil, fmt.Errorf("invalid env allowlist: %s", name)
+		}
+
+		allowlist = append(allowlist, name)
+	}
+
+	return allowlist, nil
+}
+
+// execConfig returns the config of the session exec. term is the TERM of the session, the default of the daemon(xterm) if empty.
+// If cleanEnv is true, the shell is run via `env -i` with the allowlisted variables of the container.
+func execConfig(ctx context.Context, containerID string, term string) (types.ExecConfig, error) {
+	config := createOpts
+	if !cleanEnv {
+		config.Env = append(append([]string{}, createOpts.Env...), sessionEnv()...)
+		if len(term) != 0 {
+			config.Env = append(config.Env, "TERM="+term)
+		}
+		return config, nil
+	}
+
+	ctnInfo, err := inspectContainer(ctx, containerID)
+	if err != nil {
+		return types.ExecConfig{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	var containerEnv []string
+	if ctnInfo.Config != nil {
+		containerEnv = ctnInfo.Config.Env
+	}
+	// the variables of the exec are cleared by `env -i`, so the session variables are passed as arguments
+	config.Cmd = cleanEnvCmd(term, containerEnv, envAllowlist, sessionEnv())
+
+	return config, nil
+}
+
+// cleanEnvCmd wraps imageCmd with `env -i`.
+// TERM(xterm if term is empty), HOME, USER and PATH of the container are always set so that the interactive shell works as without the wrapper.
+func cleanEnvCmd(term string, containerEnv []string, allowlist []string, extraEnv []string) []string {
+	if len(term) == 0 {
+		term = "xterm"
+	}
+
+	cmd := []string{
+		"env", "-i",
+		"TERM=" + term,
+		"HOME=" + createOpts.WorkingDir,
+		"USER=" + imageUser,
+	}
+
+	envMap := make(map[string]string, len(containerEnv))
+	for _, env := range containerEnv {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		envMap[kv[0]] = kv[1]
+	}
+
+	// without PATH, the shell falls back to its own default which may not contain the directories of the image
+	path, ok := envMap["PATH"]
+	if ok {
+		cmd = append(cmd, "PATH="+path)
+	}
+
+	for _, name := range allowlist {
+		value, ok := envMap[name]
+		if !ok || name == "PATH" {
+			continue
+		}
+		cmd = append(cmd, name+"="+value)
+	}
+	cmd = append(cmd, extraEnv...)
+
+	return append(cmd, createOpts.Cmd...)
+}
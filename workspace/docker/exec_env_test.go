@@ -0,0 +1,251 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvAllowlist(t *testing.T) {
+	tests := []struct {
+		description  string
+		strAllowlist string
+		allowlist    []string
+		isErr        bool
+	}{
+		{
+			description:  "empty",
+			strAllowlist: "",
+			allowlist:    []string{},
+		},
+		{
+			description:  "multiple names",
+			strAllowlist: "PATH, LANG,",
+			allowlist:    []string{"PATH", "LANG"},
+		},
+		{
+			description:  "invalid name",
+			strAllowlist: "PATH,LANG=C",
+			isErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			allowlist, err := parseEnvAllowlist(test.strAllowlist)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.allowlist, allowlist)
+		})
+	}
+}
+
+func TestCleanEnvCmd(t *testing.T) {
+	defaultCreateOpts := createOpts
+	defaultImageUser := imageUser
+	defer func() {
+		createOpts = defaultCreateOpts
+		imageUser = defaultImageUser
+	}()
+	imageUser = "ubuntu"
+	createOpts.WorkingDir = "/home/ubuntu"
+	createOpts.Cmd = []string{"/bin/bash"}
+
+	containerEnv := []string{
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"LANG=C.UTF-8",
+		"SECRET=password",
+		"EQUAL=a=b",
+	}
+
+	tests := []struct {
+		description  string
+		term         string
+		containerEnv []string
+		allowlist    []string
+		extraEnv     []string
+		cmd          []string
+	}{
+		{
+			description:  "empty allowlist",
+			containerEnv: containerEnv,
+			allowlist:    []string{},
+			cmd: []string{
+				"env", "-i", "TERM=xterm", "HOME=/home/ubuntu", "USER=ubuntu",
+				"PATH=/usr/local/bin:/usr/bin:/bin",
+				"/bin/bash",
+			},
+		},
+		{
+			description:  "allowlisted variables",
+			containerEnv: containerEnv,
+			allowlist:    []string{"PATH", "EQUAL"},
+			cmd: []string{
+				"env", "-i", "TERM=xterm", "HOME=/home/ubuntu", "USER=ubuntu",
+				"PATH=/usr/local/bin:/usr/bin:/bin", "EQUAL=a=b",
+				"/bin/bash",
+			},
+		},
+		{
+			description:  "missing variable",
+			containerEnv: containerEnv,
+			allowlist:    []string{"LANG", "MISSING"},
+			cmd: []string{
+				"env", "-i", "TERM=xterm", "HOME=/home/ubuntu", "USER=ubuntu",
+				"PATH=/usr/local/bin:/usr/bin:/bin", "LANG=C.UTF-8",
+				"/bin/bash",
+			},
+		},
+		{
+			description:  "extra variables",
+			containerEnv: containerEnv,
+			allowlist:    []string{"LANG"},
+			extraEnv:     []string{"RANDOM_SEED=42"},
+			cmd: []string{
+				"env", "-i", "TERM=xterm", "HOME=/home/ubuntu", "USER=ubuntu",
+				"PATH=/usr/local/bin:/usr/bin:/bin", "LANG=C.UTF-8", "RANDOM_SEED=42",
+				"/bin/bash",
+			},
+		},
+		{
+			description:  "term of the session",
+			term:         "xterm-256color",
+			containerEnv: containerEnv,
+			allowlist:    []string{},
+			cmd: []string{
+				"env", "-i", "TERM=xterm-256color", "HOME=/home/ubuntu", "USER=ubuntu",
+				"PATH=/usr/local/bin:/usr/bin:/bin",
+				"/bin/bash",
+			},
+		},
+		{
+			description:  "no path in the container",
+			containerEnv: []string{"LANG=C.UTF-8"},
+			allowlist:    []string{"PATH"},
+			cmd:          []string{"env", "-i", "TERM=xterm", "HOME=/home/ubuntu", "USER=ubuntu", "/bin/bash"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.cmd, cleanEnvCmd(test.term, test.containerEnv, test.allowlist, test.extraEnv))
+		})
+	}
+}
+
+func TestParseRandomSeed(t *testing.T) {
+	tests := []struct {
+		description string
+		strSeed     string
+		seed        string
+		isErr       bool
+	}{
+		{
+			description: "valid seed",
+			strSeed:     "42",
+			seed:        "42",
+		},
+		{
+			description: "max seed",
+			strSeed:     "4294967295",
+			seed:        "4294967295",
+		},
+		{
+			description: "negative seed",
+			strSeed:     "-1",
+			isErr:       true,
+		},
+		{
+			description: "too large seed",
+			strSeed:     "4294967296",
+			isErr:       true,
+		},
+		{
+			description: "not a number",
+			strSeed:     "seed",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			seed, err := parseRandomSeed(test.strSeed)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.seed, seed)
+		})
+	}
+}
+
+func TestExecConfig(t *testing.T) {
+	defaultCleanEnv := cleanEnv
+	defaultEnvAllowlist := envAllowlist
+	defaultRandomSeed := randomSeed
+	defer func() {
+		cleanEnv = defaultCleanEnv
+		envAllowlist = defaultEnvAllowlist
+		randomSeed = defaultRandomSeed
+	}()
+
+	inspected := false
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/containers/test/json") {
+			http.NotFound(w, r)
+			return
+		}
+
+		inspected = true
+		writeJSON(t, w, types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "test"},
+			Config: &container.Config{
+				Env: []string{"LANG=C.UTF-8", "SECRET=password"},
+			},
+		})
+	}))
+
+	cleanEnv = false
+	randomSeed = ""
+	config, err := execConfig(context.Background(), "test", "")
+	assert.NoError(t, err)
+	assert.Equal(t, createOpts.Cmd, config.Cmd)
+	assert.Empty(t, config.Env)
+	assert.False(t, inspected)
+
+	randomSeed = "42"
+	config, err = execConfig(context.Background(), "test", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"RANDOM_SEED=42", "PYTHONHASHSEED=42"}, config.Env)
+	assert.Empty(t, createOpts.Env)
+
+	// the TERM of the session
+	config, err = execConfig(context.Background(), "test", "xterm-256color")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"RANDOM_SEED=42", "PYTHONHASHSEED=42", "TERM=xterm-256color"}, config.Env)
+	assert.Empty(t, createOpts.Env)
+
+	cleanEnv = true
+	envAllowlist = []string{"LANG"}
+	config, err = execConfig(context.Background(), "test", "xterm-256color")
+	assert.NoError(t, err)
+	assert.True(t, inspected)
+	assert.Contains(t, config.Cmd, "TERM=xterm-256color")
+	assert.Equal(t, []string{"env", "-i"}, config.Cmd[:2])
+	assert.Contains(t, config.Cmd, "LANG=C.UTF-8")
+	assert.NotContains(t, config.Cmd, "SECRET=password")
+	assert.Contains(t, config.Cmd, "PYTHONHASHSEED=42")
+	assert.Equal(t, createOpts.Cmd[len(createOpts.Cmd)-1], config.Cmd[len(config.Cmd)-1])
+	assert.True(t, config.Tty)
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// execOutput runs cmd as user in the container and returns the stdout.
+// It returns an error with the stderr if cmd exits with a non-zero code.
+func execOutput(ctx context.Context, ctnName string, user string, cmd []string) (string, error) {
+	return execOutputEnv(ctx, ctnName, user, nil, cmd)
+}
+
+// execOutputEnv runs cmd like execOutput with the additional environment variables.
+func execOutputEnv(ctx context.Context, ctnName string, user string, env []string, cmd []string) (string, error) {
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, ctnName, types.ExecConfig{
+		User:         user,
+		Env:          env,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	// the attach is not bounded by the operation timeout since the output is streamed after the call
+	stream, err := cli.ContainerExecAttach(ctx, idRes.ID, types.ExecStartCheck{})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer stream.Close()
+
+	// the hijacked connection does not follow ctx
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	_, err = stdcopy.StdCopy(stdout, stderr, stream.Reader)
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("failed to run %s: %w", cmd[0], ctx.Err())
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s output: %w", cmd[0], err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ContainerExecInspect")
+	execInfo, err := cli.ContainerExecInspect(opCtx, idRes.ID)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if execInfo.ExitCode != 0 {
+		return "", fmt.Errorf("%s exited with %d: %s", cmd[0], execInfo.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// hijackHang answers an exec start like the daemon and keeps the stream open without output until the client closes it.
+func hijackHang(t *testing.T, w http.ResponseWriter) {
+	t.Helper()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		t.Fatal("response writer is not a hijacker")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		t.Fatalf("failed to hijack: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.multiplexed-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		t.Errorf("failed to write upgrade response: %s", err)
+		return
+	}
+
+	// bounded so that the server can be closed even if the client never closes the stream
+	err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Errorf("failed to set read deadline: %s", err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+// hangingExecHandler serves execs in the container whose command never exits.
+func hangingExecHandler(t *testing.T, ctnName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/"+ctnName+"/exec"):
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+			hijackHang(t, w)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestExecOutputDeadline(t *testing.T) {
+	setupTestClient(t, hangingExecHandler(t, "user-test"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := execOutput(ctx, "user-test", "root", []string{"sleep", "infinity"})
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("exec output is not bounded by the context")
+	}
+}
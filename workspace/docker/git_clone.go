@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitCloneTimeout = 10 * time.Minute
+	// gitSSHKeyEnv environment variable passing the ssh key to the clone
+	gitSSHKeyEnv = "WEBSHELL_GIT_SSH_KEY"
+)
+
+var (
+	// gitClone repository cloned into user containers on start. nil disables the clone.
+	gitClone *gitCloneConfig
+	// gitCloneTimeout maximum time of a clone
+	gitCloneTimeout = defaultGitCloneTimeout
+)
+
+type gitCloneConfig struct {
+	url    string
+	branch string
+	// dest path of the clone in the container
+	dest string
+	// token sent to https remotes as the password of x-access-token
+	token string
+	// sshKey private key for ssh remotes
+	sshKey string
+}
+
+// newGitCloneConfig validates the clone settings. An empty dest defaults to the repository name in the home directory.
+func newGitCloneConfig(url string, branch string, dest string, token string) (*gitCloneConfig, error) {
+	// values starting with - would be parsed as options of git clone
+	if strings.HasPrefix(url, "-") {
+		return nil, fmt.Errorf("invalid git clone url: %s", url)
+	}
+	if strings.HasPrefix(branch, "-") {
+		return nil, fmt.Errorf("invalid git clone branch: %s", branch)
+	}
+
+	if len(dest) == 0 {
+		name := strings.TrimSuffix(path.Base(strings.TrimSuffix(url, "/")), ".git")
+		if len(name) == 0 || name == "." || name == "/" {
+			return nil, fmt.Errorf("failed to get repository name from %s, set GIT_CLONE_PATH", url)
+		}
+
+		dest = path.Join(createOpts.WorkingDir, name)
+	}
+	if !path.IsAbs(dest) {
+		return nil, fmt.Errorf("invalid git clone path: %s is not absolute", dest)
+	}
+
+	return &gitCloneConfig{
+		url:    url,
+		branch: branch,
+		dest:   dest,
+		token:  token,
+	}, nil
+}
+
+// setSSHKey reads the private key for ssh remotes from keyPath on the server host.
+func (gc *gitCloneConfig) setSSHKey(keyPath string) error {
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read git ssh key: %w", err)
+	}
+	if !strings.Contains(string(key), "PRIVATE KEY") {
+		return fmt.Errorf("invalid git ssh key: %s is not a private key", keyPath)
+	}
+
+	gc.sshKey = string(key)
+
+	return nil
+}
+
+// cmd clones the repository unless the destination exists, so that only the first start clones it.
+func (gc *gitCloneConfig) cmd() []string {
+	args := []string{"--depth", "1"}
+	if len(gc.branch) != 0 {
+		args = append(args, "--branch", gc.branch)
+	}
+	args = append(args, "--", gc.url, gc.dest)
+
+	script := `if [ ! -e "$1" ]; then shift; exec git clone "$@"; fi`
+	if len(gc.sshKey) != 0 {
+		// ssh reads the key only from a file, which is removed after the clone
+		script = `if [ ! -e "$1" ]; then shift; k=$(mktemp) || exit 1; trap 'rm -f "$k"' EXIT; printf '%s\n' "$` + gitSSHKeyEnv + `" > "$k"; unset ` + gitSSHKeyEnv + `; GIT_SSH_COMMAND="ssh -i $k -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new" git clone "$@"; fi`
+	}
+
+	return append([]string{
+		"sh", "-c", script, "sh", gc.dest,
+	}, args...)
+}
+
+// env passes the token by environment variables(git 2.31 or later) rather than arguments so that it does not show up in the process list.
+// The ssh key is passed in the same way and written to a temporary file by cmd.
+func (gc *gitCloneConfig) env() []string {
+	var env []string
+	if len(gc.token) != 0 {
+		credential := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + gc.token))
+
+		env = append(env,
+			"GIT_TERMINAL_PROMPT=0",
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credential,
+		)
+	}
+	if len(gc.sshKey) != 0 {
+		env = append(env, gitSSHKeyEnv+"="+gc.sshKey)
+	}
+
+	return env
+}
+
+// cloneRepository clones the repository into the started container as the image user.
+// It runs in background after the start with its own timeout, so that a slow remote does not delay the login.
+// A failed clone does not fail the start since the workspace is usable without it, and the next start tries again.
+func cloneRepository(containerID string) {
+	if gitClone == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCloneTimeout)
+	defer cancel()
+
+	_, err := execOutputEnv(ctx, containerID, imageUser, gitClone.env(), gitClone.cmd())
+	if err != nil {
+		log.Printf("failed to clone %s into %s: %+v\n", gitClone.url, containerID, err)
+	}
+}
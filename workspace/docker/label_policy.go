@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// LabelPolicy labels user containers must or must not have, including the labels of the image.
+// An empty value matches any value of the label.
+type LabelPolicy struct {
+	Required  map[string]string
+	Forbidden map[string]string
+}
+
+var (
+	// containerLabels additional labels of user containers
+	containerLabels = map[string]string{}
+	// labelPolicy nil allows all labels
+	labelPolicy *LabelPolicy
+)
+
+// SetLabelPolicy replaces the label policy checked before creating user containers.
+func SetLabelPolicy(required map[string]string, forbidden map[string]string) {
+	if len(required) == 0 && len(forbidden) == 0 {
+		labelPolicy = nil
+		return
+	}
+
+	labelPolicy = &LabelPolicy{
+		Required:  required,
+		Forbidden: forbidden,
+	}
+}
+
+// parseLabels parses comma separated `key=value` or `key` labels.
+func parseLabels(strLabels string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, label := range strings.Split(strLabels, ",") {
+		label = strings.TrimSpace(label)
+		if len(label) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(label, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(key) == 0 {
+			return nil, fmt.Errorf("invalid label: %s", label)
+		}
+
+		var value string
+		if len(kv) == 2 {
+			value = strings.TrimSpace(kv[1])
+		}
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// Violation returns the reason why labels violate the policy. It returns an empty string if labels comply with the policy.
+func (lp *LabelPolicy) Violation(labels map[string]string) string {
+	reasons := []string{}
+	for key, expected := range lp.Required {
+		value, ok := labels[key]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("required label %s is missing", key))
+			continue
+		}
+		if len(expected) != 0 && value != expected {
+			reasons = append(reasons, fmt.Sprintf("label %s must be %s", key, expected))
+		}
+	}
+	for key, forbidden := range lp.Forbidden {
+		value, ok := labels[key]
+		if ok && (len(forbidden) == 0 || value == forbidden) {
+			reasons = append(reasons, fmt.Sprintf("label %s=%s is forbidden", key, value))
+		}
+	}
+	sort.Strings(reasons)
+
+	return strings.Join(reasons, ", ")
+}
+
+// checkLabelPolicy checks the labels of a new container merged with the labels of the image as the daemon does.
+func checkLabelPolicy(ctx context.Context, labels map[string]string) error {
+	if labelPolicy == nil {
+		return nil
+	}
+
+	effectiveLabels := map[string]string{}
+
+	opCtx, cancel := operationContext(ctx, "ImageInspect")
+	image, _, err := cli.ImageInspectWithRaw(opCtx, imageRef)
+	cancel()
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to inspect image: %w", err)
+	}
+	if err == nil && image.Config != nil {
+		for key, value := range image.Config.Labels {
+			effectiveLabels[key] = value
+		}
+	}
+
+	for key, value := range labels {
+		effectiveLabels[key] = value
+	}
+
+	reason := labelPolicy.Violation(effectiveLabels)
+	if len(reason) != 0 {
+		return fmt.Errorf("%w: %s", workspace.ErrPolicyViolation, reason)
+	}
+
+	return nil
+}
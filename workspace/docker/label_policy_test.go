@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		description string
+		strLabels   string
+		labels      map[string]string
+		isErr       bool
+	}{
+		{
+			description: "empty",
+			strLabels:   "",
+			labels:      map[string]string{},
+		},
+		{
+			description: "labels",
+			strLabels:   "team=cpctf, env = prod,privileged,",
+			labels: map[string]string{
+				"team":       "cpctf",
+				"env":        "prod",
+				"privileged": "",
+			},
+		},
+		{
+			description: "empty key",
+			strLabels:   "=prod",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			labels, err := parseLabels(test.strLabels)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.labels, labels)
+		})
+	}
+}
+
+func TestLabelPolicyViolation(t *testing.T) {
+	policy := &LabelPolicy{
+		Required: map[string]string{
+			"team": "",
+			"env":  "prod",
+		},
+		Forbidden: map[string]string{
+			"privileged": "",
+			"debug":      "true",
+		},
+	}
+
+	tests := []struct {
+		description string
+		labels      map[string]string
+		isViolation bool
+	}{
+		{
+			description: "compliant",
+			labels:      map[string]string{"team": "cpctf", "env": "prod", "debug": "false"},
+		},
+		{
+			description: "required label missing",
+			labels:      map[string]string{"env": "prod"},
+			isViolation: true,
+		},
+		{
+			description: "required value mismatch",
+			labels:      map[string]string{"team": "cpctf", "env": "dev"},
+			isViolation: true,
+		},
+		{
+			description: "forbidden label",
+			labels:      map[string]string{"team": "cpctf", "env": "prod", "privileged": "false"},
+			isViolation: true,
+		},
+		{
+			description: "forbidden value",
+			labels:      map[string]string{"team": "cpctf", "env": "prod", "debug": "true"},
+			isViolation: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			reason := policy.Violation(test.labels)
+			assert.Equal(t, test.isViolation, len(reason) != 0, reason)
+		})
+	}
+}
+
+func TestCheckLabelPolicy(t *testing.T) {
+	defaultImageRef := imageRef
+	defer func() {
+		imageRef = defaultImageRef
+		SetLabelPolicy(nil, nil)
+	}()
+	imageRef = "mazrean/cpctf-ubuntu:latest"
+
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/json") || !strings.Contains(r.URL.Path, "/images/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(t, w, types.ImageInspect{
+			ID: "sha256:image",
+			Config: &container.Config{
+				Labels: map[string]string{"privileged": "true"},
+			},
+		})
+	}))
+
+	SetLabelPolicy(nil, nil)
+	assert.NoError(t, checkLabelPolicy(context.Background(), map[string]string{}))
+
+	SetLabelPolicy(map[string]string{"team": ""}, nil)
+	assert.NoError(t, checkLabelPolicy(context.Background(), map[string]string{"team": "cpctf"}))
+	assert.ErrorIs(t, checkLabelPolicy(context.Background(), map[string]string{}), workspace.ErrPolicyViolation)
+
+	// the labels of the image are also checked
+	SetLabelPolicy(nil, map[string]string{"privileged": ""})
+	assert.ErrorIs(t, checkLabelPolicy(context.Background(), map[string]string{}), workspace.ErrPolicyViolation)
+}
@@ -0,0 +1,205 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// memoryOvercommitRatio the ratio of the host memory that may be allocated to user containers. 0 disables the guard.
+var memoryOvercommitRatio float64
+
+// memoryAllocation the memory limits of the user containers, loaded from the daemon on the first use.
+var memoryAllocation = &memoryAllocator{}
+
+type memoryAllocator struct {
+	locker sync.Mutex
+	// limits the memory limit of each user container by name. nil until loaded.
+	limits map[string]int64
+}
+
+// load reads the memory limits of all user containers from the daemon if they are not cached. The caller must hold the lock.
+func (ma *memoryAllocator) load(ctx context.Context) error {
+	if ma.limits != nil {
+		return nil
+	}
+
+	ctns, err := listUserContainers(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the list does not contain the limits, so each container is inspected once
+	limits := make(map[string]int64, len(ctns))
+	for _, ctn := range ctns {
+		ctnInfo, err := inspectContainer(ctx, ctn.ID)
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to inspect container: %w", err)
+		}
+
+		var limit int64
+		if ctnInfo.HostConfig != nil {
+			limit = ctnInfo.HostConfig.Memory
+		}
+		limits[strings.TrimPrefix(ctnInfo.Name, "/")] = limit
+	}
+
+	ma.limits = limits
+
+	return nil
+}
+
+// allocated returns the sum of the memory limits. The caller must hold the lock.
+func (ma *memoryAllocator) allocated() int64 {
+	var allocated int64
+	for _, limit := range ma.limits {
+		allocated += limit
+	}
+
+	return allocated
+}
+
+// Allocated returns the sum of the memory limits of all user containers.
+func (ma *memoryAllocator) Allocated(ctx context.Context) (int64, error) {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	err := ma.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return ma.allocated(), nil
+}
+
+// Limits returns a copy of the memory limit of each user container by name.
+func (ma *memoryAllocator) Limits(ctx context.Context) (map[string]int64, error) {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	err := ma.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]int64, len(ma.limits))
+	for ctnName, limit := range ma.limits {
+		limits[ctnName] = limit
+	}
+
+	return limits, nil
+}
+
+// Reserve counts memoryLimit for a new container of ctnName, or rejects it when the memory limits would exceed capacity.
+// The returned function cancels the reservation and must be called if the container is not created.
+// An existing container of ctnName is not counted as a new allocation.
+func (ma *memoryAllocator) Reserve(ctx context.Context, ctnName string, capacity int64) (func(), error) {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	err := ma.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := ma.limits[ctnName]; ok {
+		return func() {}, nil
+	}
+
+	allocated := ma.allocated()
+	if allocated+memoryLimit > capacity {
+		return nil, fmt.Errorf("%w: allocated %d bytes, capacity %d bytes", workspace.ErrInsufficientMemory, allocated, capacity)
+	}
+
+	ma.limits[ctnName] = memoryLimit
+
+	return func() {
+		ma.Release(ctnName)
+	}, nil
+}
+
+// Release removes the container of ctnName from the allocation.
+func (ma *memoryAllocator) Release(ctnName string) {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	if ma.limits != nil {
+		delete(ma.limits, ctnName)
+	}
+}
+
+// Update replaces the memory limit of the container of ctnName after its limits are changed. Nothing is done until the limits are loaded.
+func (ma *memoryAllocator) Update(ctnName string, limit int64) {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	if ma.limits != nil {
+		ma.limits[ctnName] = limit
+	}
+}
+
+// Invalidate drops the cached limits so that they are loaded from the daemon on the next use.
+func (ma *memoryAllocator) Invalidate() {
+	ma.locker.Lock()
+	defer ma.locker.Unlock()
+
+	ma.limits = nil
+}
+
+// AllocatedMemoryBytes returns the sum of the memory limits of all user containers.
+func (w *Workspace) AllocatedMemoryBytes(ctx context.Context) (int64, error) {
+	return memoryAllocation.Allocated(ctx)
+}
+
+// AvailableMemoryBytes returns the memory that can still be allocated to new user containers.
+func (w *Workspace) AvailableMemoryBytes(ctx context.Context) (int64, error) {
+	capacity, err := memoryCapacity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	allocated, err := w.AllocatedMemoryBytes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if allocated > capacity {
+		return 0, nil
+	}
+
+	return capacity - allocated, nil
+}
+
+func memoryCapacity(ctx context.Context) (int64, error) {
+	ctx, cancel := operationContext(ctx, "Info")
+	defer cancel()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	return int64(float64(info.MemTotal) * memoryOvercommitRatio), nil
+}
+
+// reserveMemory rejects a new container when the memory limits of all user containers would exceed the host memory * memoryOvercommitRatio.
+// The memory is reserved until the returned function is called, so that concurrent creations cannot overcommit together.
+func reserveMemory(ctx context.Context, ctnName string) (func(), error) {
+	if memoryOvercommitRatio <= 0 {
+		return func() {}, nil
+	}
+
+	capacity, err := memoryCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return memoryAllocation.Reserve(ctx, ctnName, capacity)
+}
@@ -0,0 +1,219 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveMemory(t *testing.T) {
+	tests := []struct {
+		description    string
+		overcommitRate float64
+		// containers the memory limit of each container by name
+		containers map[string]int64
+		ctnName    string
+		isErr      bool
+		err        error
+	}{
+		{
+			description:    "guard disabled",
+			overcommitRate: 0,
+			containers:     map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9, "user-d": 1e9},
+			ctnName:        "user-e",
+		},
+		{
+			description:    "memory available",
+			overcommitRate: 1,
+			containers:     map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9},
+			ctnName:        "user-d",
+		},
+		{
+			description:    "memory exhausted",
+			overcommitRate: 1,
+			containers:     map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9, "user-d": 1e9},
+			ctnName:        "user-e",
+			isErr:          true,
+			err:            workspace.ErrInsufficientMemory,
+		},
+		{
+			description:    "actual limits counted",
+			overcommitRate: 1,
+			containers:     map[string]int64{"user-a": 2e9, "user-b": 1.5e9},
+			ctnName:        "user-c",
+			isErr:          true,
+			err:            workspace.ErrInsufficientMemory,
+		},
+		{
+			description:    "overcommit",
+			overcommitRate: 1.5,
+			containers:     map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9, "user-d": 1e9},
+			ctnName:        "user-e",
+		},
+		{
+			description:    "existing container",
+			overcommitRate: 1,
+			containers:     map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9, "user-d": 1e9},
+			ctnName:        "user-d",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupMemoryGuardTest(t, test.overcommitRate, test.containers)
+
+			cancelReservation, err := reserveMemory(context.Background(), test.ctnName)
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			cancelReservation()
+		})
+	}
+}
+
+func TestReserveMemoryConcurrent(t *testing.T) {
+	listCount := setupMemoryGuardTest(t, 1, map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9})
+
+	// only one of the new containers fits in the capacity
+	errCh := make(chan error, 2)
+	for _, ctnName := range []string{"user-d", "user-e"} {
+		ctnName := ctnName
+		go func() {
+			_, err := reserveMemory(context.Background(), ctnName)
+			errCh <- err
+		}()
+	}
+
+	errs := []error{<-errCh, <-errCh}
+	if errs[0] == nil {
+		assert.ErrorIs(t, errs[1], workspace.ErrInsufficientMemory)
+	} else {
+		assert.ErrorIs(t, errs[0], workspace.ErrInsufficientMemory)
+		assert.NoError(t, errs[1])
+	}
+
+	// the allocation is cached instead of listed on every creation
+	assert.Equal(t, int32(1), atomic.LoadInt32(listCount))
+}
+
+func TestReserveMemoryCancel(t *testing.T) {
+	setupMemoryGuardTest(t, 1, map[string]int64{"user-a": 1e9, "user-b": 1e9, "user-c": 1e9})
+
+	cancelReservation, err := reserveMemory(context.Background(), "user-d")
+	assert.NoError(t, err)
+
+	_, err = reserveMemory(context.Background(), "user-e")
+	assert.ErrorIs(t, err, workspace.ErrInsufficientMemory)
+
+	// the memory of a container that failed to be created is available again
+	cancelReservation()
+
+	_, err = reserveMemory(context.Background(), "user-e")
+	assert.NoError(t, err)
+
+	// the memory of a removed container is available again
+	memoryAllocation.Release("user-a")
+
+	_, err = reserveMemory(context.Background(), "user-f")
+	assert.NoError(t, err)
+}
+
+func TestReserveMemoryAdmissionOverride(t *testing.T) {
+	setupMemoryGuardTest(t, 1, map[string]int64{"user-a": 1e9, "user-b": 1e9})
+
+	defaultController := admissionController
+	t.Cleanup(func() {
+		admissionController = defaultController
+	})
+	admissionController = &fixedAdmissionController{
+		admission: &Admission{
+			Allow:       true,
+			MemoryLimit: 2.5e9,
+		},
+	}
+
+	// the allocation is loaded before the override
+	cancelReservation, err := reserveMemory(context.Background(), "user-c")
+	assert.NoError(t, err)
+	cancelReservation()
+
+	err = admit(context.Background(), "user-a", "user-a")
+	assert.NoError(t, err)
+
+	allocated, err := memoryAllocation.Allocated(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3.5e9), allocated)
+
+	// the default limit of user-a would leave room for user-c
+	_, err = reserveMemory(context.Background(), "user-c")
+	assert.ErrorIs(t, err, workspace.ErrInsufficientMemory)
+}
+
+// setupMemoryGuardTest serves the containers on a 4GB host and returns the number of container lists.
+func setupMemoryGuardTest(t *testing.T, overcommitRate float64, containers map[string]int64) *int32 {
+	t.Helper()
+
+	var listCount int32
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/info"):
+			writeJSON(t, w, types.Info{
+				MemTotal: 4e9,
+			})
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			atomic.AddInt32(&listCount, 1)
+
+			ctns := make([]types.Container, 0, len(containers))
+			for name := range containers {
+				ctns = append(ctns, types.Container{
+					ID:    name,
+					Names: []string{"/" + name},
+				})
+			}
+			writeJSON(t, w, ctns)
+		case strings.HasSuffix(r.URL.Path, "/update"):
+			writeJSON(t, w, container.ContainerUpdateOKBody{})
+		case strings.HasSuffix(r.URL.Path, "/json"):
+			name := path.Base(path.Dir(r.URL.Path))
+			limit, ok := containers[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			writeJSON(t, w, types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:   name,
+					Name: "/" + name,
+					HostConfig: &container.HostConfig{
+						Resources: container.Resources{Memory: limit},
+					},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defaultMemoryLimit, defaultOvercommitRatio, defaultMemoryAllocation := memoryLimit, memoryOvercommitRatio, memoryAllocation
+	memoryLimit, memoryOvercommitRatio, memoryAllocation = 1e9, overcommitRate, &memoryAllocator{}
+	t.Cleanup(func() {
+		memoryLimit, memoryOvercommitRatio, memoryAllocation = defaultMemoryLimit, defaultOvercommitRatio, defaultMemoryAllocation
+	})
+
+	return &listCount
+}
@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+type ipRoute struct {
+	Dst     string `json:"dst"`
+	Gateway string `json:"gateway"`
+	Dev     string `json:"dev"`
+	Prefsrc string `json:"prefsrc"`
+}
+
+type ipAddr struct {
+	Ifname    string `json:"ifname"`
+	Address   string `json:"address"`
+	MTU       int    `json:"mtu"`
+	Operstate string `json:"operstate"`
+	AddrInfo  []struct {
+		Local     string `json:"local"`
+		Prefixlen int    `json:"prefixlen"`
+	} `json:"addr_info"`
+}
+
+// NetworkNamespaceInfo runs `ip -j route show` and `ip -j addr show` in the container of the user
+// and returns the routes and the interfaces of the network namespace of the container.
+// The image must contain iproute2 4.13 or later for the json output.
+func (w *Workspace) NetworkNamespaceInfo(ctx context.Context, userName values.UserName) (*values.NetworkNSInfo, error) {
+	routeOutput, err := execOutput(ctx, containerName(userName), rootUser, []string{"ip", "-j", "route", "show"})
+	if err != nil {
+		return nil, err
+	}
+
+	addrOutput, err := execOutput(ctx, containerName(userName), rootUser, []string{"ip", "-j", "addr", "show"})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseIPOutput(routeOutput, addrOutput)
+}
+
+func parseIPOutput(routeOutput string, addrOutput string) (*values.NetworkNSInfo, error) {
+	var ipRoutes []ipRoute
+	err := json.Unmarshal([]byte(routeOutput), &ipRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ip route output: %w", err)
+	}
+
+	var ipAddrs []ipAddr
+	err = json.Unmarshal([]byte(addrOutput), &ipAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ip addr output: %w", err)
+	}
+
+	routes := make([]*values.RouteEntry, 0, len(ipRoutes))
+	for _, route := range ipRoutes {
+		routes = append(routes, values.NewRouteEntry(route.Dst, route.Gateway, route.Dev, route.Prefsrc))
+	}
+
+	interfaces := make([]*values.InterfaceInfo, 0, len(ipAddrs))
+	for _, addr := range ipAddrs {
+		addresses := make([]string, 0, len(addr.AddrInfo))
+		for _, info := range addr.AddrInfo {
+			addresses = append(addresses, fmt.Sprintf("%s/%d", info.Local, info.Prefixlen))
+		}
+
+		interfaces = append(interfaces, values.NewInterfaceInfo(addr.Ifname, addr.Address, addr.MTU, addr.Operstate, addresses))
+	}
+
+	return values.NewNetworkNSInfo(routes, interfaces), nil
+}
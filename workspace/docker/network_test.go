@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIPOutput(t *testing.T) {
+	routeOutput := `[{"dst":"default","gateway":"172.17.0.1","dev":"eth0","flags":[]},{"dst":"172.17.0.0/16","dev":"eth0","protocol":"kernel","scope":"link","prefsrc":"172.17.0.2","flags":[]}]`
+	addrOutput := `[{"ifindex":1,"ifname":"lo","flags":["LOOPBACK","UP","LOWER_UP"],"mtu":65536,"qdisc":"noqueue","operstate":"UNKNOWN","group":"default","txqlen":1000,"link_type":"loopback","address":"00:00:00:00:00:00","broadcast":"00:00:00:00:00:00","addr_info":[{"family":"inet","local":"127.0.0.1","prefixlen":8,"scope":"host","label":"lo","valid_life_time":4294967295,"preferred_life_time":4294967295}]},{"ifindex":4,"link_index":5,"ifname":"eth0","flags":["BROADCAST","MULTICAST","UP","LOWER_UP"],"mtu":1500,"qdisc":"noqueue","operstate":"UP","group":"default","link_type":"ether","address":"02:42:ac:11:00:02","broadcast":"ff:ff:ff:ff:ff:ff","link_netnsid":0,"addr_info":[{"family":"inet","local":"172.17.0.2","prefixlen":16,"broadcast":"172.17.255.255","scope":"global","label":"eth0","valid_life_time":4294967295,"preferred_life_time":4294967295}]}]`
+
+	info, err := parseIPOutput(routeOutput, addrOutput)
+	assert.NoError(t, err)
+
+	routes := info.Routes()
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, "default", routes[0].Destination())
+		assert.Equal(t, "172.17.0.1", routes[0].Gateway())
+		assert.Equal(t, "eth0", routes[0].Device())
+		assert.Equal(t, "172.17.0.0/16", routes[1].Destination())
+		assert.Empty(t, routes[1].Gateway())
+		assert.Equal(t, "172.17.0.2", routes[1].Source())
+	}
+
+	interfaces := info.Interfaces()
+	if assert.Len(t, interfaces, 2) {
+		assert.Equal(t, "lo", interfaces[0].Name())
+		assert.Equal(t, []string{"127.0.0.1/8"}, interfaces[0].Addresses())
+		assert.Equal(t, "eth0", interfaces[1].Name())
+		assert.Equal(t, "02:42:ac:11:00:02", interfaces[1].MACAddress())
+		assert.Equal(t, 1500, interfaces[1].MTU())
+		assert.Equal(t, "UP", interfaces[1].State())
+		assert.Equal(t, []string{"172.17.0.2/16"}, interfaces[1].Addresses())
+	}
+
+	_, err = parseIPOutput("Object \"-j\" is unknown", addrOutput)
+	assert.Error(t, err)
+}
+
+func TestNetworkNamespaceInfoDeadline(t *testing.T) {
+	// ip hangs in the container
+	setupTestClient(t, hangingExecHandler(t, containerName("test")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := (&Workspace{}).NetworkNamespaceInfo(ctx, "test")
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("ip is not bounded by the context")
+	}
+}
@@ -0,0 +1,167 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+const (
+	rootUser = "root"
+	// captureDir keeps the pid of tcpdump out of reach of the container user, who could otherwise make root signal any process through the pid file.
+	captureDir = "/run/webshell-capture"
+	// captureDirCheck fails unless captureDir is a directory of root rather than a symlink or a directory planted by the container user.
+	captureDirCheck = `[ -d "$d" ] && [ ! -L "$d" ] && [ -O "$d" ]`
+)
+
+// captureStderrLimit bytes of the stderr of tcpdump kept for the error of a failed capture
+const captureStderrLimit = 4096
+
+// captureIDExpression capture IDs are used in the path of the pid file
+var captureIDExpression = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// PacketCapture pcap stream of a tcpdump started by StartPacketCapture.
+// The stream fails with the stderr of tcpdump if tcpdump exits with a non-zero code.
+type PacketCapture struct {
+	*io.PipeReader
+	stream types.HijackedResponse
+	// id names the pid file of the capture, so that concurrent captures in a container do not stop each other
+	id string
+}
+
+// ID returns the ID passed to StopPacketCapture.
+func (pc *PacketCapture) ID() string {
+	return pc.id
+}
+
+func (pc *PacketCapture) Close() error {
+	pc.stream.Close()
+
+	return pc.PipeReader.Close()
+}
+
+// limitedBuffer keeps the first limit bytes written and drops the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	rest := lb.limit - lb.Len()
+	if rest > len(p) {
+		rest = len(p)
+	}
+	if rest > 0 {
+		lb.Buffer.Write(p[:rest])
+	}
+
+	return len(p), nil
+}
+
+func newCaptureID() (string, error) {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate capture id: %w", err)
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// StartPacketCapture runs tcpdump in the container of the user and streams the raw pcap.
+// The image must contain tcpdump.
+func (w *Workspace) StartPacketCapture(ctx context.Context, userName values.UserName, iface string, filterExpr string) (*PacketCapture, error) {
+	captureID, err := newCaptureID()
+	if err != nil {
+		return nil, err
+	}
+
+	// the interface, the filter and the capture ID are passed as positional parameters to avoid shell injection
+	script := fmt.Sprintf(`set -f; umask 077; d=%s; mkdir -p "$d"; %s || { echo "unsafe $d" >&2; exit 1; }; chmod 700 "$d"; tcpdump -U -w - -i "$1" $2 & echo $! > "$d/$3.pid"; wait $!; s=$?; rm -f "$d/$3.pid"; exit $s`, captureDir, captureDirCheck)
+
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, containerName(userName), types.ExecConfig{
+		User:         rootUser,
+		Cmd:          []string{"sh", "-c", script, "sh", iface, filterExpr, captureID},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	// the attach is not bounded by the operation timeout since the capture is streamed until it is stopped
+	stream, err := cli.ContainerExecAttach(ctx, idRes.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach exec: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		stderr := &limitedBuffer{limit: captureStderrLimit}
+		_, err := stdcopy.StdCopy(pw, stderr, stream.Reader)
+		if err != nil {
+			log.Printf("failed to copy packet capture: %+v\n", err)
+			pw.CloseWithError(err)
+			return
+		}
+
+		opCtx, cancel := operationContext(context.Background(), "ContainerExecInspect")
+		execInfo, err := cli.ContainerExecInspect(opCtx, idRes.ID)
+		cancel()
+		if err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to inspect exec: %w", err))
+			return
+		}
+		if !execInfo.Running && execInfo.ExitCode != 0 {
+			pw.CloseWithError(fmt.Errorf("tcpdump exited with %d: %s", execInfo.ExitCode, strings.TrimSpace(stderr.String())))
+			return
+		}
+
+		pw.Close()
+	}()
+
+	return &PacketCapture{
+		PipeReader: pr,
+		stream:     stream,
+		id:         captureID,
+	}, nil
+}
+
+// StopPacketCapture sends SIGINT to the tcpdump of the capture started by StartPacketCapture.
+func (w *Workspace) StopPacketCapture(ctx context.Context, userName values.UserName, captureID string) error {
+	if !captureIDExpression.MatchString(captureID) {
+		return fmt.Errorf("invalid capture id: %s", captureID)
+	}
+
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, containerName(userName), types.ExecConfig{
+		User: rootUser,
+		Cmd:  []string{"sh", "-c", fmt.Sprintf(`d=%s; %s || exit 1; kill -INT "$(cat "$d/$1.pid")"`, captureDir, captureDirCheck), "sh", captureID},
+	})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ContainerExecStart")
+	defer cancel()
+
+	err = cli.ContainerExecStart(opCtx, idRes.ID, types.ExecStartCheck{})
+	if err != nil {
+		return fmt.Errorf("failed to start exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+// hijackStdout answers an exec start like the daemon and writes stdout as a multiplexed stream.
+func hijackStdout(t *testing.T, w http.ResponseWriter, stdout []byte) {
+	t.Helper()
+
+	hijackOutput(t, w, stdout, nil)
+}
+
+// hijackOutput answers an exec start like the daemon and writes stdout and stderr as a multiplexed stream.
+func hijackOutput(t *testing.T, w http.ResponseWriter, stdout []byte, stderr []byte) {
+	t.Helper()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		t.Fatal("response writer is not a hijacker")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		t.Fatalf("failed to hijack: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.multiplexed-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	if err != nil {
+		t.Errorf("failed to write upgrade response: %s", err)
+		return
+	}
+
+	_, err = stdcopy.NewStdWriter(buf, stdcopy.Stdout).Write(stdout)
+	if err != nil {
+		t.Errorf("failed to write stdout: %s", err)
+		return
+	}
+
+	if len(stderr) != 0 {
+		_, err = stdcopy.NewStdWriter(buf, stdcopy.Stderr).Write(stderr)
+		if err != nil {
+			t.Errorf("failed to write stderr: %s", err)
+			return
+		}
+	}
+
+	err = buf.Flush()
+	if err != nil {
+		t.Errorf("failed to flush: %s", err)
+	}
+}
+
+func TestStartPacketCapture(t *testing.T) {
+	pcap := []byte("\xd4\xc3\xb2\xa1pcap")
+
+	tests := []struct {
+		description string
+		stderr      []byte
+		exitCode    int
+		isErr       bool
+	}{
+		{
+			description: "stopped",
+			stderr:      []byte("listening on eth0\n1 packet captured\n"),
+		},
+		{
+			description: "tcpdump failed",
+			stderr:      []byte("tcpdump: eth9: No such device exists\n"),
+			exitCode:    1,
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var execConfig types.ExecConfig
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/containers/user-test/exec"):
+					err := json.NewDecoder(r.Body).Decode(&execConfig)
+					if err != nil {
+						t.Errorf("failed to decode exec config: %s", err)
+					}
+
+					w.WriteHeader(http.StatusCreated)
+					writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+				case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+					hijackOutput(t, w, pcap, test.stderr)
+				case strings.HasSuffix(r.URL.Path, "/exec/exec_id/json"):
+					writeJSON(t, w, types.ContainerExecInspect{ExecID: "exec_id", ExitCode: test.exitCode})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			capture, err := (&Workspace{}).StartPacketCapture(context.Background(), "test", "eth0", "port 80")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer capture.Close()
+
+			actual, err := io.ReadAll(capture)
+			assert.Equal(t, pcap, actual)
+			if test.isErr {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), "No such device exists")
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, rootUser, execConfig.User)
+			if assert.Len(t, execConfig.Cmd, 7) {
+				// the pid file is kept in the directory of root, not in the world writable /tmp
+				assert.Contains(t, execConfig.Cmd[2], captureDir)
+				assert.Contains(t, execConfig.Cmd[2], captureDirCheck)
+				assert.NotContains(t, execConfig.Cmd[2], "/tmp")
+				// user input is passed as positional parameters
+				assert.Equal(t, []string{"eth0", "port 80", capture.ID()}, execConfig.Cmd[4:])
+			}
+		})
+	}
+}
+
+func TestPacketCaptureID(t *testing.T) {
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/user-test/exec"):
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+			hijackStdout(t, w, nil)
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/json"):
+			writeJSON(t, w, types.ContainerExecInspect{ExecID: "exec_id"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	// concurrent captures of a container have their own pid files
+	capture1, err := (&Workspace{}).StartPacketCapture(context.Background(), "test", "eth0", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer capture1.Close()
+
+	capture2, err := (&Workspace{}).StartPacketCapture(context.Background(), "test", "eth0", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer capture2.Close()
+
+	assert.Regexp(t, captureIDExpression, capture1.ID())
+	assert.NotEqual(t, capture1.ID(), capture2.ID())
+}
+
+func TestStopPacketCapture(t *testing.T) {
+	var execConfig types.ExecConfig
+	started := false
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/user-test/exec"):
+			err := json.NewDecoder(r.Body).Decode(&execConfig)
+			if err != nil {
+				t.Errorf("failed to decode exec config: %s", err)
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+			started = true
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	err := (&Workspace{}).StopPacketCapture(context.Background(), "test", "0123456789abcdef")
+	assert.NoError(t, err)
+	assert.True(t, started)
+
+	assert.Equal(t, rootUser, execConfig.User)
+	if assert.Len(t, execConfig.Cmd, 5) {
+		// the pid file is read only after the directory is checked to belong to root
+		script := execConfig.Cmd[2]
+		assert.Less(t, strings.Index(script, captureDirCheck), strings.Index(script, "kill"))
+		assert.NotContains(t, script, "/tmp")
+		assert.Equal(t, "0123456789abcdef", execConfig.Cmd[4])
+	}
+
+	// the capture id is a part of the path of the pid file
+	started = false
+	err = (&Workspace{}).StopPacketCapture(context.Background(), "test", "../../etc/passwd")
+	assert.Error(t, err)
+	assert.False(t, started)
+}
@@ -0,0 +1,42 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var provisionQueueGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Help:      "Number of container provisioning operations waiting for a slot.",
+	Namespace: "webshell",
+	Name:      "provisioning_queue_depth",
+})
+
+// provisionSemaphore limits the concurrent container creations so that a burst of new users does not overwhelm the daemon
+var provisionSemaphore = make(chan struct{}, runtime.NumCPU())
+
+func setProvisionConcurrency(concurrency int) {
+	provisionSemaphore = make(chan struct{}, concurrency)
+}
+
+// acquireProvision waits for a provisioning slot until ctx is done.
+// The returned func releases the slot.
+func acquireProvision(ctx context.Context) (func(), error) {
+	semaphore := provisionSemaphore
+
+	provisionQueueGauge.Inc()
+	defer provisionQueueGauge.Dec()
+
+	select {
+	case semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for provisioning: %w", ctx.Err())
+	}
+
+	return func() {
+		<-semaphore
+	}, nil
+}
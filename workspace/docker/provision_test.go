@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireProvision(t *testing.T) {
+	defaultSemaphore := provisionSemaphore
+	defer func() {
+		provisionSemaphore = defaultSemaphore
+	}()
+	setProvisionConcurrency(1)
+
+	release, err := acquireProvision(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = acquireProvision(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := acquireProvision(context.Background())
+		assert.NoError(t, err)
+		release()
+		close(acquired)
+	}()
+
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("provisioning slot was not released")
+	}
+}
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// PruneImages removes the images of the IMAGE_NAME repository replaced by newer pulls.
+// Images used by a container, including stopped user containers, are kept.
+// Stopped containers and volumes are not pruned since they hold the data of users.
+func (w *Workspace) PruneImages(ctx context.Context) (*values.PruneReport, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image name: %w", err)
+	}
+
+	opCtx, cancel := operationContext(ctx, "ImageInspect")
+	current, _, err := cli.ImageInspectWithRaw(opCtx, imageRef)
+	cancel()
+	if err != nil && !errdefs.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ImageList")
+	images, err := cli.ImageList(opCtx, types.ImageListOptions{
+		Filters: filters.NewArgs(filters.Arg("reference", reference.FamiliarName(named))),
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var (
+		imagesDeleted  int
+		spaceReclaimed int64
+	)
+	for _, image := range images {
+		if image.ID == current.ID {
+			continue
+		}
+
+		opCtx, cancel := operationContext(ctx, "ImageRemove")
+		_, err := cli.ImageRemove(opCtx, image.ID, types.ImageRemoveOptions{
+			PruneChildren: true,
+		})
+		cancel()
+		if errdefs.IsConflict(err) {
+			// used by a container
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove image %s: %w", image.ID, err)
+		}
+
+		imagesDeleted++
+		spaceReclaimed += image.Size
+	}
+
+	return values.NewPruneReport(0, imagesDeleted, 0, spaceReclaimed), nil
+}
+
+// SystemPrune removes the stopped containers labeled app=separated-webshell, and also the unused images and volumes with the label if requested.
+// Stopped user containers are removed too, so it is meant to be run while the server is down; the server creates the workspaces of the users again on start.
+func (w *Workspace) SystemPrune(ctx context.Context, pruneImages bool, pruneVolumes bool) (*values.PruneReport, error) {
+	labelFilter := filters.Arg("label", appLabel+"="+appLabelValue)
+
+	opCtx, cancel := operationContext(ctx, "ContainersPrune")
+	containersReport, err := cli.ContainersPrune(opCtx, filters.NewArgs(labelFilter))
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune containers: %w", err)
+	}
+
+	if len(containersReport.ContainersDeleted) != 0 {
+		// the report has only the IDs, so the limits are loaded again
+		memoryAllocation.Invalidate()
+	}
+
+	spaceReclaimed := int64(containersReport.SpaceReclaimed)
+
+	var imagesDeleted int
+	if pruneImages {
+		opCtx, cancel := operationContext(ctx, "ImagesPrune")
+		// not only dangling images: old images of the label are left tagged by other repositories
+		imagesReport, err := cli.ImagesPrune(opCtx, filters.NewArgs(labelFilter, filters.Arg("dangling", "false")))
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune images: %w", err)
+		}
+
+		for _, item := range imagesReport.ImagesDeleted {
+			if len(item.Deleted) != 0 {
+				imagesDeleted++
+			}
+		}
+		spaceReclaimed += int64(imagesReport.SpaceReclaimed)
+	}
+
+	var volumesDeleted int
+	if pruneVolumes {
+		opCtx, cancel := operationContext(ctx, "VolumesPrune")
+		volumesReport, err := cli.VolumesPrune(opCtx, filters.NewArgs(labelFilter))
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune volumes: %w", err)
+		}
+
+		volumesDeleted = len(volumesReport.VolumesDeleted)
+		spaceReclaimed += int64(volumesReport.SpaceReclaimed)
+	}
+
+	return values.NewPruneReport(len(containersReport.ContainersDeleted), imagesDeleted, volumesDeleted, spaceReclaimed), nil
+}
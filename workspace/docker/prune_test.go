@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneImages(t *testing.T) {
+	defaultImageRef := imageRef
+	defer func() {
+		imageRef = defaultImageRef
+	}()
+	imageRef = "mazrean/cpctf-ubuntu:latest"
+
+	removed := []string{}
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/images/mazrean/cpctf-ubuntu:latest/json"):
+			writeJSON(t, w, types.ImageInspect{ID: "sha256:current"})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/images/json"):
+			assert.Contains(t, r.URL.Query().Get("filters"), "mazrean/cpctf-ubuntu")
+			writeJSON(t, w, []types.ImageSummary{
+				{ID: "sha256:current", Size: 100},
+				{ID: "sha256:old", Size: 200},
+				{ID: "sha256:used", Size: 300},
+			})
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/images/sha256:used"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			writeJSON(t, w, errorResponse{Message: "image is being used by stopped container"})
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/images/sha256:old"):
+			removed = append(removed, "sha256:old")
+			writeJSON(t, w, []types.ImageDeleteResponseItem{{Deleted: "sha256:old"}})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+
+	report, err := (&Workspace{}).PruneImages(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.ImagesDeleted())
+	assert.Equal(t, int64(200), report.SpaceReclaimed())
+	assert.Equal(t, []string{"sha256:old"}, removed)
+}
+
+func TestSystemPrune(t *testing.T) {
+	tests := []struct {
+		description  string
+		pruneImages  bool
+		pruneVolumes bool
+		report       *values.PruneReport
+	}{
+		{
+			description: "containers only",
+			report:      values.NewPruneReport(2, 0, 0, 10),
+		},
+		{
+			description:  "all",
+			pruneImages:  true,
+			pruneVolumes: true,
+			report:       values.NewPruneReport(2, 1, 1, 10+200+3000),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pruned := []string{}
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Contains(t, r.URL.Query().Get("filters"), `"app=separated-webshell"`)
+
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/containers/prune"):
+					pruned = append(pruned, "containers")
+					writeJSON(t, w, types.ContainersPruneReport{
+						ContainersDeleted: []string{"a", "b"},
+						SpaceReclaimed:    10,
+					})
+				case strings.HasSuffix(r.URL.Path, "/images/prune"):
+					pruned = append(pruned, "images")
+					assert.Contains(t, r.URL.Query().Get("filters"), `"dangling":{"false":true}`)
+					writeJSON(t, w, types.ImagesPruneReport{
+						ImagesDeleted: []types.ImageDeleteResponseItem{
+							{Untagged: "mazrean/cpctf-ubuntu:old"},
+							{Deleted: "sha256:old"},
+						},
+						SpaceReclaimed: 200,
+					})
+				case strings.HasSuffix(r.URL.Path, "/volumes/prune"):
+					pruned = append(pruned, "volumes")
+					writeJSON(t, w, types.VolumesPruneReport{
+						VolumesDeleted: []string{"volume"},
+						SpaceReclaimed: 3000,
+					})
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					http.NotFound(w, r)
+				}
+			}))
+
+			report, err := (&Workspace{}).SystemPrune(context.Background(), test.pruneImages, test.pruneVolumes)
+			assert.NoError(t, err)
+			assert.Equal(t, test.report, report)
+
+			expected := []string{"containers"}
+			if test.pruneImages {
+				expected = append(expected, "images")
+			}
+			if test.pruneVolumes {
+				expected = append(expected, "volumes")
+			}
+			assert.Equal(t, expected, pruned)
+		})
+	}
+}
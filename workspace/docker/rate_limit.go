@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var rateLimitDelayHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Help:      "Time docker api calls were delayed by the rate limit.",
+	Namespace: "webshell",
+	Name:      "daemon_rate_limit_delay_seconds",
+	Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"operation"})
+
+// daemonRateLimiter limits the rate of docker api calls made through operationContext, which are all calls but the streaming ones(exec attach, events, image pull).
+// nil means no limit.
+var daemonRateLimiter *rate.Limiter
+
+// setDaemonRateLimit limits docker api calls to rps calls per second with bursts of burst calls.
+func setDaemonRateLimit(rps float64, burst int) {
+	daemonRateLimiter = rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// waitRateLimit waits until the docker api call is allowed by the rate limit or ctx is done.
+// The call is not rejected when ctx is done since it fails on ctx by itself.
+func waitRateLimit(ctx context.Context, operation string) {
+	if daemonRateLimiter == nil {
+		return
+	}
+
+	reservation := daemonRateLimiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	startedAt := time.Now()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		// the token is returned for the other calls
+		reservation.Cancel()
+	}
+	rateLimitDelayHistogram.WithLabelValues(operation).Observe(time.Since(startedAt).Seconds())
+}
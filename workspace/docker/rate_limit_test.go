@@ -0,0 +1,34 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitRateLimit(t *testing.T) {
+	defaultDaemonRateLimiter := daemonRateLimiter
+	defer func() {
+		daemonRateLimiter = defaultDaemonRateLimiter
+	}()
+	setDaemonRateLimit(10, 1)
+
+	startedAt := time.Now()
+	waitRateLimit(context.Background(), "ContainerCreate")
+	assert.Less(t, int64(time.Since(startedAt)), int64(50*time.Millisecond), "burst call is delayed")
+
+	// the next call waits for a token(100ms at 10 calls per second)
+	startedAt = time.Now()
+	waitRateLimit(context.Background(), "ContainerCreate")
+	assert.GreaterOrEqual(t, int64(time.Since(startedAt)), int64(50*time.Millisecond), "call over the rate is not delayed")
+
+	// a cancelled call does not wait
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	startedAt = time.Now()
+	waitRateLimit(ctx, "ContainerCreate")
+	assert.Less(t, int64(time.Since(startedAt)), int64(50*time.Millisecond), "cancelled call is delayed")
+}
@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+const (
+	defaultReadinessTimeout = 30 * time.Second
+
+	tcpProbe  = "tcp"
+	fileProbe = "file"
+	execProbe = "exec"
+)
+
+var (
+	// readinessCmd the command run in the container until it exits with 0. nil disables the readiness gate.
+	readinessCmd      []string
+	readinessTimeout  = defaultReadinessTimeout
+	readinessInterval = 500 * time.Millisecond
+)
+
+// parseReadinessProbe parses the probe in the form of tcp:<port>, file:<path> or exec:<command>.
+func parseReadinessProbe(probe string) ([]string, error) {
+	probeArgs := strings.SplitN(probe, ":", 2)
+	if len(probeArgs) != 2 || len(probeArgs[1]) == 0 {
+		return nil, fmt.Errorf("invalid readiness probe: %s", probe)
+	}
+	probeType, arg := probeArgs[0], probeArgs[1]
+
+	switch probeType {
+	case tcpProbe:
+		port, err := strconv.ParseUint(arg, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid readiness probe port: %w", err)
+		}
+
+		// a listening socket has the remote address 0 and the state 0A in /proc/net/tcp{,6}
+		return []string{"sh", "-c", fmt.Sprintf("grep -qE ':%04X [0-9A-F]+:0000 0A' /proc/net/tcp /proc/net/tcp6", port)}, nil
+	case fileProbe:
+		return []string{"test", "-e", arg}, nil
+	case execProbe:
+		return []string{"sh", "-c", arg}, nil
+	}
+
+	return nil, fmt.Errorf("invalid readiness probe type: %s", probeType)
+}
+
+// waitReady runs readinessCmd in the container until it succeeds or readinessTimeout expires.
+func waitReady(ctx context.Context, containerID string) error {
+	if readinessCmd == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := probe(ctx, containerID)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to probe readiness of %s: %+v\n", containerID, err)
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w: not ready in %s", workspace.ErrWorkspaceNotReady, readinessTimeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func probe(ctx context.Context, containerID string) (bool, error) {
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, containerID, types.ExecConfig{
+		Cmd: readinessCmd,
+	})
+	cancel()
+	if err != nil {
+		return false, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ContainerExecStart")
+	err = cli.ContainerExecStart(opCtx, idRes.ID, types.ExecStartCheck{
+		Detach: true,
+	})
+	cancel()
+	if err != nil {
+		return false, fmt.Errorf("failed to start exec: %w", err)
+	}
+
+	for {
+		opCtx, cancel := operationContext(ctx, "ContainerExecInspect")
+		execInfo, err := cli.ContainerExecInspect(opCtx, idRes.ID)
+		cancel()
+		if err != nil {
+			return false, fmt.Errorf("failed to inspect exec: %w", err)
+		}
+		if !execInfo.Running {
+			return execInfo.ExitCode == 0, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(readinessInterval / 10):
+		}
+	}
+}
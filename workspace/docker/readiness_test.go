@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReadinessProbe(t *testing.T) {
+	tests := []struct {
+		description string
+		probe       string
+		expected    []string
+		isErr       bool
+	}{
+		{
+			description: "tcp",
+			probe:       "tcp:5900",
+			expected:    []string{"sh", "-c", "grep -qE ':170C [0-9A-F]+:0000 0A' /proc/net/tcp /proc/net/tcp6"},
+		},
+		{
+			description: "file",
+			probe:       "file:/tmp/ready",
+			expected:    []string{"test", "-e", "/tmp/ready"},
+		},
+		{
+			description: "exec",
+			probe:       "exec:pgrep Xvnc",
+			expected:    []string{"sh", "-c", "pgrep Xvnc"},
+		},
+		{
+			description: "invalid port",
+			probe:       "tcp:vnc",
+			isErr:       true,
+		},
+		{
+			description: "unknown type",
+			probe:       "http:/healthz",
+			isErr:       true,
+		},
+		{
+			description: "no argument",
+			probe:       "file:",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cmd, err := parseReadinessProbe(test.probe)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, cmd)
+		})
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	tests := []struct {
+		description string
+		exitCodes   []int
+		timeout     time.Duration
+		isErr       bool
+		err         error
+	}{
+		{
+			description: "ready",
+			exitCodes:   []int{0},
+			timeout:     time.Second,
+		},
+		{
+			description: "ready after retry",
+			exitCodes:   []int{1, 1, 0},
+			timeout:     time.Second,
+		},
+		{
+			description: "not ready",
+			exitCodes:   []int{1},
+			timeout:     50 * time.Millisecond,
+			isErr:       true,
+			err:         workspace.ErrWorkspaceNotReady,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			probeNum := 0
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/containers/container_id/exec"):
+					writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+				case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+					w.WriteHeader(http.StatusOK)
+				case strings.HasSuffix(r.URL.Path, "/exec/exec_id/json"):
+					exitCode := test.exitCodes[len(test.exitCodes)-1]
+					if probeNum < len(test.exitCodes) {
+						exitCode = test.exitCodes[probeNum]
+					}
+					probeNum++
+
+					writeJSON(t, w, types.ContainerExecInspect{
+						ExecID:   "exec_id",
+						Running:  false,
+						ExitCode: exitCode,
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			defaultCmd, defaultTimeout, defaultInterval := readinessCmd, readinessTimeout, readinessInterval
+			readinessCmd, readinessTimeout, readinessInterval = []string{"test", "-e", "/tmp/ready"}, test.timeout, time.Millisecond
+			defer func() {
+				readinessCmd, readinessTimeout, readinessInterval = defaultCmd, defaultTimeout, defaultInterval
+			}()
+
+			err := waitReady(context.Background(), "container_id")
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.exitCodes), probeNum)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// registryFallbacks registries tried in order when the image cannot be pulled from the registry of IMAGE_NAME(e.g. mirror.gcr.io).
+var registryFallbacks []string
+
+// parseRegistryFallbacks parses comma separated registries.
+func parseRegistryFallbacks(strRegistries string) ([]string, error) {
+	registries := []string{}
+	for _, registry := range strings.Split(strRegistries, ",") {
+		registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+		if len(registry) == 0 {
+			continue
+		}
+		if strings.Contains(registry, "://") {
+			return nil, fmt.Errorf("invalid registry %s: scheme is not allowed", registry)
+		}
+
+		registries = append(registries, registry)
+	}
+
+	return registries, nil
+}
+
+// fallbackRef returns the image reference of ref in registry, keeping the path and the tag or digest.
+func fallbackRef(ref string, registry string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image name: %w", err)
+	}
+
+	fallback := registry + "/" + reference.Path(named)
+	if tagged, ok := named.(reference.Tagged); ok {
+		fallback += ":" + tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		fallback += "@" + digested.Digest().String()
+	}
+
+	_, err = reference.ParseNormalizedNamed(fallback)
+	if err != nil {
+		return "", fmt.Errorf("invalid image name in registry %s: %w", registry, err)
+	}
+
+	return fallback, nil
+}
+
+// pullImage pulls IMAGE_NAME, trying the fallback registries in order if the pull fails.
+// An image pulled from a fallback registry is tagged as IMAGE_NAME so that containers are created from it by the same name.
+func pullImage(ctx context.Context) error {
+	err := pull(ctx, imageRef)
+	if err == nil {
+		return nil
+	}
+	if len(registryFallbacks) == 0 {
+		return err
+	}
+	log.Printf("failed to pull image %s: %+v\n", imageRef, err)
+
+	for _, registry := range registryFallbacks {
+		ref, refErr := fallbackRef(imageRef, registry)
+		if refErr != nil {
+			return refErr
+		}
+
+		err = pull(ctx, ref)
+		if err != nil {
+			log.Printf("failed to pull image %s: %+v\n", ref, err)
+			continue
+		}
+
+		opCtx, cancel := operationContext(ctx, "ImageTag")
+		err = cli.ImageTag(opCtx, ref, imageRef)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to tag image %s as %s: %w", ref, imageRef, err)
+		}
+		log.Printf("pulled image %s from fallback registry %s\n", imageRef, registry)
+
+		return nil
+	}
+
+	return fmt.Errorf("failed to pull image from all registries: %w", err)
+}
+
+type pullMessage struct {
+	Error string `json:"error"`
+}
+
+// pull pulls the image and copies the progress to stdout.
+// Failures after the pull started(e.g. unknown manifest) are only reported in the progress stream.
+func pull(ctx context.Context, ref string) error {
+	// the pull is not bounded by the operation timeout since the progress is streamed until the image is downloaded
+	reader, err := cli.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(io.TeeReader(reader, os.Stdout))
+	for {
+		var message pullMessage
+		err := decoder.Decode(&message)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read pull progress: %w", err)
+		}
+		if len(message.Error) != 0 {
+			return fmt.Errorf("failed to pull image: %s", message.Error)
+		}
+	}
+}
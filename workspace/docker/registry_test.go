@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackRef(t *testing.T) {
+	tests := []struct {
+		description string
+		ref         string
+		registry    string
+		expected    string
+		isErr       bool
+	}{
+		{
+			description: "docker hub image",
+			ref:         "mazrean/cpctf-ubuntu:latest",
+			registry:    "mirror.gcr.io",
+			expected:    "mirror.gcr.io/mazrean/cpctf-ubuntu:latest",
+		},
+		{
+			description: "official image",
+			ref:         "ubuntu",
+			registry:    "mirror.gcr.io",
+			expected:    "mirror.gcr.io/library/ubuntu",
+		},
+		{
+			description: "other registry",
+			ref:         "ghcr.io/mazrean/cpctf-ubuntu:v1",
+			registry:    "registry.example.com:5000",
+			expected:    "registry.example.com:5000/mazrean/cpctf-ubuntu:v1",
+		},
+		{
+			description: "digest",
+			ref:         "ubuntu@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			registry:    "mirror.gcr.io",
+			expected:    "mirror.gcr.io/library/ubuntu@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		},
+		{
+			description: "invalid registry",
+			ref:         "ubuntu",
+			registry:    "Invalid Registry",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ref, err := fallbackRef(test.ref, test.registry)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ref)
+		})
+	}
+}
+
+func TestPullImage(t *testing.T) {
+	tests := []struct {
+		description string
+		fallbacks   []string
+		available   map[string]bool
+		tagged      string
+		isErr       bool
+	}{
+		{
+			description: "primary registry",
+			fallbacks:   []string{"mirror.gcr.io"},
+			available: map[string]bool{
+				"mazrean/cpctf-ubuntu": true,
+			},
+		},
+		{
+			description: "fallback registry",
+			fallbacks:   []string{"registry.example.com", "mirror.gcr.io"},
+			available: map[string]bool{
+				"mirror.gcr.io/mazrean/cpctf-ubuntu": true,
+			},
+			tagged: "mirror.gcr.io/mazrean/cpctf-ubuntu:latest",
+		},
+		{
+			description: "no registry available",
+			fallbacks:   []string{"mirror.gcr.io"},
+			isErr:       true,
+		},
+		{
+			description: "no fallback",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tagged := ""
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/images/create"):
+					w.Header().Set("Content-Type", "application/json")
+					if test.available[r.URL.Query().Get("fromImage")] {
+						writeJSON(t, w, map[string]string{"status": "Downloaded newer image"})
+						return
+					}
+
+					// the daemon reports pull failures in the progress stream
+					writeJSON(t, w, map[string]string{"status": "Pulling from " + r.URL.Query().Get("fromImage")})
+					writeJSON(t, w, map[string]string{"error": "manifest unknown"})
+				case strings.HasSuffix(r.URL.Path, "/tag"):
+					assert.Equal(t, "mazrean/cpctf-ubuntu", r.URL.Query().Get("repo"))
+					assert.Equal(t, "latest", r.URL.Query().Get("tag"))
+					tagged = strings.TrimSuffix(strings.TrimPrefix(r.URL.Path[strings.Index(r.URL.Path, "/images/"):], "/images/"), "/tag")
+					w.WriteHeader(http.StatusCreated)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			defaultImageRef := imageRef
+			defaultRegistryFallbacks := registryFallbacks
+			imageRef = "mazrean/cpctf-ubuntu:latest"
+			registryFallbacks = test.fallbacks
+			defer func() {
+				imageRef = defaultImageRef
+				registryFallbacks = defaultRegistryFallbacks
+			}()
+
+			err := pullImage(context.Background())
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.tagged, tagged)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// resourceExhaustedBackoff period in which new sessions are rejected after the file descriptors are exhausted
+const resourceExhaustedBackoff = 10 * time.Second
+
+// exhaustedUntil unix nano time until which new sessions are rejected
+var exhaustedUntil int64
+
+// isResourceExhausted reports whether err is caused by the exhaustion of file descriptors on this host or the docker host.
+func isResourceExhausted(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+		return true
+	}
+
+	// errors of the docker daemon are passed as messages
+	return strings.Contains(err.Error(), "too many open files")
+}
+
+// resourceExhaustedError wraps err with ErrResourceExhausted and starts the backoff if err is caused by the exhaustion of file descriptors.
+func resourceExhaustedError(err error) error {
+	if !isResourceExhausted(err) {
+		return err
+	}
+
+	atomic.StoreInt64(&exhaustedUntil, time.Now().Add(resourceExhaustedBackoff).UnixNano())
+
+	return fmt.Errorf("%w: %v", workspace.ErrResourceExhausted, err)
+}
+
+// checkResourceExhausted rejects new sessions during the backoff.
+func checkResourceExhausted() error {
+	until := atomic.LoadInt64(&exhaustedUntil)
+	if time.Now().UnixNano() < until {
+		return fmt.Errorf("%w: backing off until %s", workspace.ErrResourceExhausted, time.Unix(0, until).Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsResourceExhausted(t *testing.T) {
+	tests := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{
+			description: "nil",
+			err:         nil,
+			expected:    false,
+		},
+		{
+			description: "EMFILE",
+			err:         fmt.Errorf("error during connect: %w", os.NewSyscallError("socket", syscall.EMFILE)),
+			expected:    true,
+		},
+		{
+			description: "ENFILE",
+			err:         os.NewSyscallError("open", syscall.ENFILE),
+			expected:    true,
+		},
+		{
+			description: "daemon error",
+			err:         errors.New("Error response from daemon: open /proc/self/fd: too many open files"),
+			expected:    true,
+		},
+		{
+			description: "other error",
+			err:         os.NewSyscallError("socket", syscall.ECONNREFUSED),
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expected, isResourceExhausted(test.err))
+		})
+	}
+}
+
+func TestConnectResourceExhausted(t *testing.T) {
+	dialNum := 0
+	testCli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://127.0.0.1:2375"),
+		client.WithDialContext(func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			dialNum++
+			return nil, &net.OpError{
+				Op:  "dial",
+				Net: network,
+				Err: os.NewSyscallError("socket", syscall.EMFILE),
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create test client: %s", err)
+	}
+
+	defaultCli := cli
+	cli = testCli
+	defer func() {
+		cli = defaultCli
+		atomic.StoreInt64(&exhaustedUntil, 0)
+	}()
+
+	wc := NewWorkspaceConnection()
+	ws := domain.NewWorkspace("container_id", "user-test", "test")
+
+	_, err = wc.Connect(context.Background(), ws, "")
+	assert.ErrorIs(t, err, workspace.ErrResourceExhausted)
+	assert.Equal(t, 1, dialNum)
+
+	// new sessions are rejected without calling the daemon during the backoff
+	_, err = wc.Connect(context.Background(), ws, "")
+	assert.ErrorIs(t, err, workspace.ErrResourceExhausted)
+	assert.Equal(t, 1, dialNum)
+}
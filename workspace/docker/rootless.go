@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+const defaultSocketPath = "/var/run/docker.sock"
+
+var (
+	// rootless whether the daemon runs without root(rootless docker or rootless podman)
+	rootless bool
+	// allowUnlimitedRootless if true, workspaces run without resource limits on a rootless daemon that cannot apply them
+	allowUnlimitedRootless bool
+)
+
+// socketPaths candidates of the daemon socket in order of preference when DOCKER_HOST is empty.
+func socketPaths() []string {
+	paths := []string{defaultSocketPath}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(runtimeDir) != 0 {
+		paths = append(paths,
+			filepath.Join(runtimeDir, "docker.sock"),
+			filepath.Join(runtimeDir, "podman", "podman.sock"),
+		)
+	}
+
+	return append(paths, "/run/podman/podman.sock")
+}
+
+// detectHost returns the host of the first existing daemon socket.
+// It returns false if DOCKER_HOST is set or no socket is found so that the client default is used.
+func detectHost() (string, bool) {
+	if len(os.Getenv("DOCKER_HOST")) != 0 {
+		return "", false
+	}
+
+	for _, path := range socketPaths() {
+		_, err := os.Stat(path)
+		if err == nil {
+			return "unix://" + path, true
+		}
+	}
+
+	return "", false
+}
+
+func clientOpts() []client.Opt {
+	opts := []client.Opt{
+		client.FromEnv,
+		client.WithAPIVersionNegotiation(),
+	}
+
+	host, ok := detectHost()
+	if ok {
+		opts = append(opts, client.WithHost(host))
+	}
+
+	// applied after the host so that the transport configured for the host is tuned
+	return append(opts, withIdleConnections)
+}
+
+// checkRootless detects the rootless daemon.
+// The startup fails if the rootless daemon cannot apply the resource limits(cgroup v1), unless allowUnlimitedRootless is set.
+func checkRootless(ctx context.Context) error {
+	opCtx, cancel := operationContext(ctx, "Info")
+	info, err := cli.Info(opCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	rootless = false
+	for _, option := range info.SecurityOptions {
+		if option == "name=rootless" {
+			rootless = true
+			break
+		}
+	}
+	if !rootless {
+		return nil
+	}
+
+	log.Println("docker daemon is rootless")
+
+	if info.CgroupVersion != "2" && memoryOvercommitRatio > 0 {
+		// the guard counts the memory limits, which would all be 0
+		return fmt.Errorf("rootless docker daemon with cgroup v%s cannot limit memory, which MEMORY_OVERCOMMIT_RATIO requires", info.CgroupVersion)
+	}
+
+	if info.CgroupVersion != "2" && (cpuLimit != 0 || memoryLimit != 0) {
+		if !allowUnlimitedRootless {
+			return fmt.Errorf("rootless docker daemon with cgroup v%s cannot limit resources, set ALLOW_UNLIMITED_ROOTLESS=true to run workspaces without CPU_LIMIT and MEMORY_LIMIT", info.CgroupVersion)
+		}
+
+		log.Printf("WARNING: rootless docker daemon with cgroup v%s cannot limit resources, workspaces run WITHOUT CPU and memory limits(ALLOW_UNLIMITED_ROOTLESS=true)\n", info.CgroupVersion)
+		cpuLimit = 0
+		memoryLimit = 0
+	}
+
+	return nil
+}
+
+// unsupportedFeatureError explains errors caused by features the rootless daemon does not support.
+func unsupportedFeatureError(err error) error {
+	if err == nil || !rootless {
+		return err
+	}
+
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "cgroup") ||
+		strings.Contains(message, "operation not permitted") ||
+		strings.Contains(message, "permission denied") {
+		return fmt.Errorf("the configuration may not be supported by the rootless docker daemon(see CONTAINER_RUNTIME, CPU_LIMIT and MEMORY_LIMIT): %w", err)
+	}
+
+	return err
+}
@@ -0,0 +1,210 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectHost(t *testing.T) {
+	if _, err := os.Stat(defaultSocketPath); err == nil {
+		t.Skip("docker socket exists")
+	}
+
+	runtimeDir := t.TempDir()
+
+	defaultDockerHost, dockerHostOK := os.LookupEnv("DOCKER_HOST")
+	defaultRuntimeDir, runtimeDirOK := os.LookupEnv("XDG_RUNTIME_DIR")
+	defer func() {
+		if dockerHostOK {
+			os.Setenv("DOCKER_HOST", defaultDockerHost)
+		} else {
+			os.Unsetenv("DOCKER_HOST")
+		}
+		if runtimeDirOK {
+			os.Setenv("XDG_RUNTIME_DIR", defaultRuntimeDir)
+		} else {
+			os.Unsetenv("XDG_RUNTIME_DIR")
+		}
+	}()
+	os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	tests := []struct {
+		description string
+		dockerHost  string
+		sockets     []string
+		host        string
+		ok          bool
+	}{
+		{
+			description: "no socket",
+			ok:          false,
+		},
+		{
+			description: "rootless docker",
+			sockets:     []string{"docker.sock"},
+			host:        "unix://" + filepath.Join(runtimeDir, "docker.sock"),
+			ok:          true,
+		},
+		{
+			description: "rootless podman",
+			sockets:     []string{"podman/podman.sock"},
+			host:        "unix://" + filepath.Join(runtimeDir, "podman", "podman.sock"),
+			ok:          true,
+		},
+		{
+			description: "docker preferred to podman",
+			sockets:     []string{"docker.sock", "podman/podman.sock"},
+			host:        "unix://" + filepath.Join(runtimeDir, "docker.sock"),
+			ok:          true,
+		},
+		{
+			description: "DOCKER_HOST set",
+			dockerHost:  "tcp://127.0.0.1:2375",
+			sockets:     []string{"docker.sock"},
+			ok:          false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			os.RemoveAll(filepath.Join(runtimeDir, "docker.sock"))
+			os.RemoveAll(filepath.Join(runtimeDir, "podman"))
+			for _, socket := range test.sockets {
+				path := filepath.Join(runtimeDir, socket)
+				err := os.MkdirAll(filepath.Dir(path), 0o755)
+				if err != nil {
+					t.Fatalf("failed to create dir: %s", err)
+				}
+				err = os.WriteFile(path, nil, 0o600)
+				if err != nil {
+					t.Fatalf("failed to create socket: %s", err)
+				}
+			}
+
+			if len(test.dockerHost) != 0 {
+				os.Setenv("DOCKER_HOST", test.dockerHost)
+			} else {
+				os.Unsetenv("DOCKER_HOST")
+			}
+
+			host, ok := detectHost()
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.host, host)
+		})
+	}
+}
+
+func TestCheckRootless(t *testing.T) {
+	tests := []struct {
+		description     string
+		securityOptions []string
+		cgroupVersion   string
+		overcommitRatio float64
+		allowUnlimited  bool
+		rootless        bool
+		limited         bool
+		isErr           bool
+	}{
+		{
+			description:     "rootful",
+			securityOptions: []string{"name=seccomp,profile=default"},
+			cgroupVersion:   "1",
+			rootless:        false,
+			limited:         true,
+		},
+		{
+			description:     "rootless cgroup v2",
+			securityOptions: []string{"name=seccomp,profile=default", "name=rootless"},
+			cgroupVersion:   "2",
+			rootless:        true,
+			limited:         true,
+		},
+		{
+			description:     "rootless cgroup v1",
+			securityOptions: []string{"name=seccomp,profile=default", "name=rootless"},
+			cgroupVersion:   "1",
+			isErr:           true,
+		},
+		{
+			description:     "rootless cgroup v1 allowed unlimited",
+			securityOptions: []string{"name=seccomp,profile=default", "name=rootless"},
+			cgroupVersion:   "1",
+			allowUnlimited:  true,
+			rootless:        true,
+			limited:         false,
+		},
+		{
+			description:     "rootless cgroup v1 with memory guard",
+			securityOptions: []string{"name=seccomp,profile=default", "name=rootless"},
+			cgroupVersion:   "1",
+			overcommitRatio: 1,
+			allowUnlimited:  true,
+			isErr:           true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/info") {
+					http.NotFound(w, r)
+					return
+				}
+
+				writeJSON(t, w, types.Info{
+					SecurityOptions: test.securityOptions,
+					CgroupVersion:   test.cgroupVersion,
+				})
+			}))
+
+			defaultCPULimit, defaultMemoryLimit, defaultOvercommitRatio := cpuLimit, memoryLimit, memoryOvercommitRatio
+			cpuLimit, memoryLimit, memoryOvercommitRatio = 500000000, 1024*1e6, test.overcommitRatio
+			allowUnlimitedRootless = test.allowUnlimited
+			defer func() {
+				cpuLimit, memoryLimit, memoryOvercommitRatio = defaultCPULimit, defaultMemoryLimit, defaultOvercommitRatio
+				allowUnlimitedRootless = false
+				rootless = false
+			}()
+
+			err := checkRootless(context.Background())
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.rootless, rootless)
+			assert.Equal(t, test.limited, memoryLimit != 0)
+			assert.Equal(t, test.limited, cpuLimit != 0)
+		})
+	}
+}
+
+func TestUnsupportedFeatureError(t *testing.T) {
+	cgroupErr := errors.New("OCI runtime create failed: cgroups: cgroup mountpoint does not exist")
+
+	defer func() {
+		rootless = false
+	}()
+
+	rootless = false
+	assert.Equal(t, cgroupErr, unsupportedFeatureError(cgroupErr))
+
+	rootless = true
+	err := unsupportedFeatureError(cgroupErr)
+	assert.ErrorIs(t, err, cgroupErr)
+	assert.Contains(t, err.Error(), "rootless")
+
+	otherErr := errors.New("no such image")
+	assert.Equal(t, otherErr, unsupportedFeatureError(otherErr))
+	assert.NoError(t, unsupportedFeatureError(nil))
+}
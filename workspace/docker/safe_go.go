@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"log"
+	"runtime/debug"
+)
+
+// goRecovered runs fn in a goroutine and logs a panic in it instead of crashing the server.
+func goRecovered(name string, fn func()) {
+	go func() {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			log.Printf("panic in %s: %v\n%s", name, r, debug.Stack())
+		}()
+
+		fn()
+	}()
+}
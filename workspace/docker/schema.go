@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mazrean/separated-webshell/domain"
+)
+
+const (
+	schemaVersionLabel = "webshell.schema_version"
+	// schemaVersion bump this when the container configuration changes so that existing containers can be detected and reset
+	schemaVersion = 2
+
+	// appLabel marks every container created by the server, so that SystemPrune can select them
+	appLabel      = "app"
+	appLabelValue = "separated-webshell"
+)
+
+// containerSchemaVersion returns the schema version of the container. Containers created before the label was introduced are version 0.
+func containerSchemaVersion(labels map[string]string) int {
+	version, err := strconv.Atoi(labels[schemaVersionLabel])
+	if err != nil {
+		return 0
+	}
+
+	return version
+}
+
+// ListOutdated lists the workspaces created with an older container configuration.
+// They are upgraded by recreating them.
+func (w *Workspace) ListOutdated(ctx context.Context) ([]*domain.Workspace, error) {
+	ctns, err := listUserContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []*domain.Workspace{}
+	for _, ctn := range ctns {
+		if containerSchemaVersion(ctn.Labels) >= schemaVersion {
+			continue
+		}
+
+		ws, ok := workspaceFromContainer(ctn)
+		if ok {
+			workspaces = append(workspaces, ws)
+		}
+	}
+
+	return workspaces, nil
+}
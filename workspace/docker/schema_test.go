@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListOutdated(t *testing.T) {
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/containers/json") {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(t, w, []types.Container{
+			{
+				ID:     "current",
+				Names:  []string{"/user-current"},
+				Labels: map[string]string{schemaVersionLabel: strconv.Itoa(schemaVersion)},
+				State:  "running",
+			},
+			{
+				ID:     "old",
+				Names:  []string{"/user-old"},
+				Labels: map[string]string{schemaVersionLabel: strconv.Itoa(schemaVersion - 1)},
+				State:  "exited",
+			},
+			{
+				ID:    "unlabeled",
+				Names: []string{"/user-unlabeled"},
+				State: "running",
+			},
+		})
+	}))
+
+	w := &Workspace{}
+	workspaces, err := w.ListOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list outdated workspaces: %s", err)
+	}
+
+	userNames := make([]values.UserName, 0, len(workspaces))
+	for _, ws := range workspaces {
+		userNames = append(userNames, ws.UserName())
+	}
+	assert.Equal(t, []values.UserName{"old", "unlabeled"}, userNames)
+}
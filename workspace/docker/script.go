@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// RunScript runs script with the shell of the image as the image user in the running container of the user and returns the exit code.
+// The script is passed to the shell via stdin without a TTY. The exec is abandoned when ctx is done.
+func (w *Workspace) RunScript(ctx context.Context, userName values.UserName, script string, stdout io.Writer, stderr io.Writer) (int, error) {
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, containerName(userName), types.ExecConfig{
+		User:         imageUser,
+		WorkingDir:   createOpts.WorkingDir,
+		Cmd:          createOpts.Cmd,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	cancel()
+	if errdefs.IsNotFound(err) {
+		return 0, workspace.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	// the attach is not bounded by the operation timeout since the script runs until it exits or ctx is done
+	stream, err := cli.ContainerExecAttach(ctx, idRes.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer stream.Close()
+
+	// the hijacked connection does not follow ctx
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+
+	go func() {
+		_, err := io.WriteString(stream.Conn, script)
+		if err == nil {
+			err = stream.CloseWrite()
+		}
+		if err != nil && ctx.Err() == nil {
+			stream.Close()
+		}
+	}()
+
+	_, err = stdcopy.StdCopy(stdout, stderr, stream.Reader)
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("failed to run script: %w", ctx.Err())
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read script output: %w", err)
+	}
+
+	opCtx, cancel = operationContext(ctx, "ContainerExecInspect")
+	execInfo, err := cli.ContainerExecInspect(opCtx, idRes.ID)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	return execInfo.ExitCode, nil
+}
@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var sloExceededCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Help:      "Number of docker api calls slower than the SLO.",
+	Namespace: "webshell",
+	Name:      "slo_exceeded_total",
+}, []string{"operation"})
+
+// operationSLO latency objective of a single docker api call made through operationContext. 0 disables the measurement.
+var operationSLO time.Duration
+
+// observeSLO counts the docker api call started at startedAt if it was slower than operationSLO.
+func observeSLO(operation string, startedAt time.Time) {
+	if operationSLO <= 0 {
+		return
+	}
+
+	if time.Since(startedAt) > operationSLO {
+		sloExceededCounter.WithLabelValues(operation).Inc()
+	}
+}
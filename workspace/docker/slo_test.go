@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveSLO(t *testing.T) {
+	defaultOperationSLO := operationSLO
+	defaultOperationTimeout := operationTimeout
+	defer func() {
+		operationSLO = defaultOperationSLO
+		operationTimeout = defaultOperationTimeout
+	}()
+	operationSLO = 10 * time.Millisecond
+
+	for _, timeout := range []time.Duration{0, time.Second} {
+		operationTimeout = timeout
+		counter := sloExceededCounter.WithLabelValues("ContainerStart")
+		before := testutil.ToFloat64(counter)
+
+		_, cancel := operationContext(context.Background(), "ContainerStart")
+		cancel()
+		assert.Equal(t, before, testutil.ToFloat64(counter), "fast call is counted(timeout: %s)", timeout)
+
+		_, cancel = operationContext(context.Background(), "ContainerStart")
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		assert.Equal(t, before+1, testutil.ToFloat64(counter), "slow call is not counted(timeout: %s)", timeout)
+	}
+}
+
+func TestProbeSLO(t *testing.T) {
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/container_id/exec"):
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, types.IDResponse{ID: "exec_id"})
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/start"):
+			w.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(r.URL.Path, "/exec/exec_id/json"):
+			// the daemon is slow to answer the readiness probe
+			time.Sleep(20 * time.Millisecond)
+			writeJSON(t, w, types.ContainerExecInspect{ExitCode: 0, Running: false})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defaultOperationSLO := operationSLO
+	defer func() {
+		operationSLO = defaultOperationSLO
+	}()
+	operationSLO = 10 * time.Millisecond
+
+	counter := sloExceededCounter.WithLabelValues("ContainerExecInspect")
+	before := testutil.ToFloat64(counter)
+
+	ok, err := probe(context.Background(), "container_id")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
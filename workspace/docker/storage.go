@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/docker/docker/api/types"
+)
+
+// storageQuota size limit of the writable layer of user containers(e.g. 10G). empty means no limit.
+var storageQuota string
+
+// quotaStorageDrivers storage drivers accepting the size storage option
+var quotaStorageDrivers = map[string]bool{
+	"overlay2":      true,
+	"devicemapper":  true,
+	"btrfs":         true,
+	"zfs":           true,
+	"windowsfilter": true,
+}
+
+// DetectStorageDriver returns the storage driver of the daemon.
+func (w *Workspace) DetectStorageDriver(ctx context.Context) (string, error) {
+	ctx, cancel := operationContext(ctx, "Info")
+	defer cancel()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	return info.Driver, nil
+}
+
+// checkStorageDriver disables STORAGE_QUOTA with a warning if the storage driver of the daemon does not support it.
+// overlay2 supports it only on xfs mounted with pquota. The mount option is not visible, so the creation fails if it is missing.
+func checkStorageDriver(ctx context.Context) error {
+	if len(storageQuota) == 0 {
+		return nil
+	}
+
+	opCtx, cancel := operationContext(ctx, "Info")
+	info, err := cli.Info(opCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	if !quotaStorageDrivers[info.Driver] || (info.Driver == "overlay2" && backingFilesystem(info) != "xfs") {
+		log.Printf("storage driver %s(backing filesystem: %s) does not support STORAGE_QUOTA, it is ignored\n", info.Driver, backingFilesystem(info))
+		storageQuota = ""
+	}
+
+	return nil
+}
+
+func backingFilesystem(info types.Info) string {
+	for _, status := range info.DriverStatus {
+		if status[0] == "Backing Filesystem" {
+			return status[1]
+		}
+	}
+
+	return "unknown"
+}
+
+// storageOpt storage options of user containers.
+func storageOpt() map[string]string {
+	if len(storageQuota) == 0 {
+		return nil
+	}
+
+	return map[string]string{
+		"size": storageQuota,
+	}
+}
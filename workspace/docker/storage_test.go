@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStorageDriver(t *testing.T) {
+	tests := []struct {
+		description  string
+		driver       string
+		driverStatus [][2]string
+		isQuota      bool
+	}{
+		{
+			description:  "overlay2 on xfs",
+			driver:       "overlay2",
+			driverStatus: [][2]string{{"Backing Filesystem", "xfs"}},
+			isQuota:      true,
+		},
+		{
+			description:  "overlay2 on ext4",
+			driver:       "overlay2",
+			driverStatus: [][2]string{{"Backing Filesystem", "extfs"}},
+			isQuota:      false,
+		},
+		{
+			description: "btrfs",
+			driver:      "btrfs",
+			isQuota:     true,
+		},
+		{
+			description: "vfs",
+			driver:      "vfs",
+			isQuota:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/info") {
+					http.NotFound(w, r)
+					return
+				}
+
+				writeJSON(t, w, types.Info{
+					Driver:       test.driver,
+					DriverStatus: test.driverStatus,
+				})
+			}))
+
+			defaultStorageQuota := storageQuota
+			defer func() {
+				storageQuota = defaultStorageQuota
+			}()
+			storageQuota = "10G"
+
+			err := checkStorageDriver(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.isQuota, storageOpt() != nil)
+
+			driver, err := (&Workspace{}).DetectStorageDriver(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.driver, driver)
+		})
+	}
+}
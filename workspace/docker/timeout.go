@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// operationTimeout upper bound of a single docker api call. 0 means no bound other than the caller's context.
+var operationTimeout time.Duration
+
+// operationContext bounds a single docker api call by operationTimeout regardless of the deadline of ctx.
+func operationContext(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	return timeoutContext(ctx, operation, operationTimeout)
+}
+
+// timeoutContext bounds a docker api call by timeout if operationTimeout is enabled.
+// The returned cancel func logs a warning when the call was aborted by the timeout rather than by ctx.
+// It also waits for the rate limit of docker api calls, which does not count towards the timeout nor the SLO.
+// The cancel func counts calls slower than the SLO, so it must be called right after the call completes.
+func timeoutContext(ctx context.Context, operation string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	waitRateLimit(ctx, operation)
+	startedAt := time.Now()
+
+	if operationTimeout <= 0 {
+		return ctx, func() {
+			observeSLO(operation, startedAt)
+		}
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+
+	return opCtx, func() {
+		observeSLO(operation, startedAt)
+		if ctx.Err() == nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+			log.Printf("docker %s timed out after %s\n", operation, timeout)
+		}
+		cancel()
+	}
+}
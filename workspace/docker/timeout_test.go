@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	tests := []struct {
+		description string
+		timeout     time.Duration
+		delay       time.Duration
+		isErr       bool
+	}{
+		{
+			description: "no timeout",
+			timeout:     0,
+			delay:       50 * time.Millisecond,
+		},
+		{
+			description: "within timeout",
+			timeout:     time.Second,
+			delay:       0,
+		},
+		{
+			description: "timeout exceeded",
+			timeout:     20 * time.Millisecond,
+			delay:       time.Second,
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/containers/user-test/json") {
+					http.NotFound(w, r)
+					return
+				}
+
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(test.delay):
+				}
+
+				writeJSON(t, w, types.ContainerJSON{
+					ContainerJSONBase: &types.ContainerJSONBase{
+						ID:    "container_id",
+						State: &types.ContainerState{},
+					},
+				})
+			}))
+
+			defaultTimeout := operationTimeout
+			operationTimeout = test.timeout
+			defer func() {
+				operationTimeout = defaultTimeout
+			}()
+
+			w := &Workspace{}
+			_, err := w.Get(context.Background(), "test")
+			if test.isErr {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const hostTimezone = "host"
+
+// timezone IANA time zone of user containers(e.g. Asia/Tokyo). empty means the default of the image(usually UTC).
+var timezone string
+
+// SetTimezone sets the time zone of user containers created after this call.
+// Only TZ is set to tz, since the server may not run on the docker host and a zoneinfo file of the server cannot be mounted.
+// The name is checked against the zoneinfo of the server, and the image must contain tzdata to resolve it.
+func SetTimezone(tz string) error {
+	if len(tz) == 0 {
+		timezone = ""
+		return nil
+	}
+
+	// Local is not a name the container can resolve
+	if tz == "Local" || strings.HasPrefix(tz, "/") || strings.Contains(tz, "..") {
+		return fmt.Errorf("invalid timezone: %s", tz)
+	}
+
+	_, err := time.LoadLocation(tz)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	timezone = tz
+
+	return nil
+}
+
+// SetTimezoneFromHost sets the time zone of user containers to the one of the host.
+func SetTimezoneFromHost() error {
+	tz, err := detectHostTimezone()
+	if err != nil {
+		return err
+	}
+
+	return SetTimezone(tz)
+}
+
+// detectHostTimezone returns the name of the local time zone.
+// time.Local is named "Local" unless TZ is set, so the name is resolved from the /etc/localtime symlink.
+func detectHostTimezone() (string, error) {
+	if name := time.Local.String(); name != "Local" {
+		return name, nil
+	}
+
+	tz, ok := os.LookupEnv("TZ")
+	if ok {
+		if len(tz) == 0 {
+			return "UTC", nil
+		}
+
+		return strings.TrimPrefix(tz, ":"), nil
+	}
+
+	return timezoneFromLocaltime("/etc/localtime")
+}
+
+func timezoneFromLocaltime(localtime string) (string, error) {
+	target, err := filepath.EvalSymlinks(localtime)
+	if errors.Is(err, os.ErrNotExist) {
+		return "UTC", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", localtime, err)
+	}
+
+	i := strings.Index(target, "/zoneinfo/")
+	if i < 0 {
+		return "", fmt.Errorf("failed to detect host timezone: %s is not a zoneinfo file", target)
+	}
+
+	return target[i+len("/zoneinfo/"):], nil
+}
+
+// timezoneEnv environment variables of user containers for the time zone.
+func timezoneEnv() []string {
+	if len(timezone) == 0 {
+		return nil
+	}
+
+	return []string{"TZ=" + timezone}
+}
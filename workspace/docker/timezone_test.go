@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTimezone(t *testing.T) {
+	tests := []struct {
+		description string
+		tz          string
+		env         []string
+		isErr       bool
+	}{
+		{
+			description: "empty",
+			tz:          "",
+		},
+		{
+			description: "valid timezone",
+			tz:          "Asia/Tokyo",
+			env:         []string{"TZ=Asia/Tokyo"},
+		},
+		{
+			description: "unknown timezone",
+			tz:          "Mars/Olympus",
+			isErr:       true,
+		},
+		{
+			description: "local",
+			tz:          "Local",
+			isErr:       true,
+		},
+		{
+			description: "path traversal",
+			tz:          "../../etc/passwd",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defaultTimezone := timezone
+			defer func() {
+				timezone = defaultTimezone
+			}()
+
+			err := SetTimezone(test.tz)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.env, timezoneEnv())
+		})
+	}
+}
+
+func TestTimezoneFromLocaltime(t *testing.T) {
+	dir := t.TempDir()
+
+	zoneinfo := filepath.Join(dir, "zoneinfo", "Asia")
+	err := os.MkdirAll(zoneinfo, 0755)
+	if err != nil {
+		t.Fatalf("failed to create zoneinfo directory: %s", err)
+	}
+	err = os.WriteFile(filepath.Join(zoneinfo, "Tokyo"), nil, 0644)
+	if err != nil {
+		t.Fatalf("failed to create zoneinfo file: %s", err)
+	}
+
+	localtime := filepath.Join(dir, "localtime")
+	err = os.Symlink(filepath.Join(zoneinfo, "Tokyo"), localtime)
+	if err != nil {
+		t.Fatalf("failed to create localtime symlink: %s", err)
+	}
+
+	tz, err := timezoneFromLocaltime(localtime)
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", tz)
+
+	tz, err = timezoneFromLocaltime(filepath.Join(dir, "not_exist"))
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", tz)
+}
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// withIdleConnections configures the idle connection pool of the client from DOCKER_MAX_IDLE_CONNS and DOCKER_IDLE_CONN_TIMEOUT.
+// The default transport keeps only 2 idle connections to the daemon, so concurrent calls open new connections.
+func withIdleConnections(c *client.Client) error {
+	// HTTPClient copies the client but shares the transport
+	transport, ok := c.HTTPClient().Transport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+
+	strMaxIdleConns := os.Getenv("DOCKER_MAX_IDLE_CONNS")
+	if len(strMaxIdleConns) != 0 {
+		maxIdleConns, err := strconv.Atoi(strMaxIdleConns)
+		if err != nil {
+			return fmt.Errorf("invalid docker max idle connections: %w", err)
+		}
+		if maxIdleConns < 0 {
+			return fmt.Errorf("invalid docker max idle connections: %d", maxIdleConns)
+		}
+
+		// all connections go to the single daemon host
+		transport.MaxIdleConns = maxIdleConns
+		transport.MaxIdleConnsPerHost = maxIdleConns
+	}
+
+	strIdleConnTimeout := os.Getenv("DOCKER_IDLE_CONN_TIMEOUT")
+	if len(strIdleConnTimeout) != 0 {
+		idleConnTimeout, err := time.ParseDuration(strIdleConnTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid docker idle connection timeout: %w", err)
+		}
+		if idleConnTimeout < 0 {
+			return fmt.Errorf("invalid docker idle connection timeout: %s", idleConnTimeout)
+		}
+
+		transport.IdleConnTimeout = idleConnTimeout
+	}
+
+	return nil
+}
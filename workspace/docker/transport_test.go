@@ -0,0 +1,78 @@
+package docker
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIdleConnections(t *testing.T) {
+	tests := []struct {
+		description     string
+		maxIdleConns    string
+		idleConnTimeout string
+		expectedMax     int
+		expectedTimeout time.Duration
+		isErr           bool
+	}{
+		{
+			description: "default",
+		},
+		{
+			description:     "high throughput",
+			maxIdleConns:    "100",
+			idleConnTimeout: "90s",
+			expectedMax:     100,
+			expectedTimeout: 90 * time.Second,
+		},
+		{
+			description:  "invalid max idle connections",
+			maxIdleConns: "-1",
+			isErr:        true,
+		},
+		{
+			description:     "invalid idle connection timeout",
+			idleConnTimeout: "forever",
+			isErr:           true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			for key, value := range map[string]string{
+				"DOCKER_MAX_IDLE_CONNS":    test.maxIdleConns,
+				"DOCKER_IDLE_CONN_TIMEOUT": test.idleConnTimeout,
+			} {
+				defaultValue, ok := os.LookupEnv(key)
+				defer func(key string) {
+					if ok {
+						os.Setenv(key, defaultValue)
+					} else {
+						os.Unsetenv(key)
+					}
+				}(key)
+				os.Setenv(key, value)
+			}
+
+			testCli, err := client.NewClientWithOpts(client.WithHost("tcp://127.0.0.1:2375"), withIdleConnections)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			transport, ok := testCli.HTTPClient().Transport.(*http.Transport)
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.Equal(t, test.expectedMax, transport.MaxIdleConnsPerHost)
+			assert.Equal(t, test.expectedTimeout, transport.IdleConnTimeout)
+		})
+	}
+}
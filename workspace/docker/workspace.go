@@ -2,16 +2,26 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/signal"
+	"github.com/docker/go-units"
 	"github.com/mazrean/separated-webshell/domain"
 	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -19,12 +29,22 @@ import (
 const (
 	upLabel   = "up"
 	downLabel = "down"
+
+	defaultNamePrefix = "user"
 )
 
+// namePrefixExpression the container names allowed by the docker daemon
+var namePrefixExpression = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 var (
+	stopSignal  = os.Getenv("STOP_SIGNAL")
 	stopTimeout = 10 * time.Second
 	cpuLimit    int64
 	memoryLimit int64
+	// removeVolumes whether Remove deletes the anonymous volumes. Recreate always keeps them.
+	removeVolumes bool
+	// containerNamePrefix user containers are named <containerNamePrefix><user name>
+	containerNamePrefix = defaultNamePrefix + "-"
 )
 
 var containerCounter = promauto.NewGaugeVec(prometheus.GaugeOpts{
@@ -34,7 +54,35 @@ var containerCounter = promauto.NewGaugeVec(prometheus.GaugeOpts{
 }, []string{"status"})
 
 func containerName(userName values.UserName) string {
-	return fmt.Sprintf("user-%s", userName)
+	return containerNamePrefix + string(userName)
+}
+
+func userNameFromContainerName(ctnName string) (values.UserName, bool) {
+	ctnName = strings.TrimPrefix(ctnName, "/")
+	if !strings.HasPrefix(ctnName, containerNamePrefix) {
+		return "", false
+	}
+
+	userName, err := values.NewUserName(strings.TrimPrefix(ctnName, containerNamePrefix))
+	if err != nil {
+		return "", false
+	}
+
+	return userName, true
+}
+
+// isImageNotFound reports whether err is caused by the removed image of the container.
+func isImageNotFound(err error) bool {
+	return errdefs.IsNotFound(err) && strings.Contains(strings.ToLower(err.Error()), "image")
+}
+
+// isCancelled reports whether the docker api call was aborted by the cancellation or the deadline of the context.
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func imageNotFoundError(err error) error {
+	return fmt.Errorf("failed to start container(%s): %w", err, workspace.ErrImageNotFound)
 }
 
 type Workspace struct{}
@@ -52,27 +100,344 @@ func NewWorkspace() (*Workspace, error) {
 	}
 	memoryLimit = int64(floatMemoryLimit * 1e6)
 
+	return NewReadOnlyWorkspace()
+}
+
+// NewReadOnlyWorkspace reads the configuration except CPU_LIMIT and MEMORY_LIMIT for tools that only inspect workspaces.
+// The workspace must not be used to create or start containers, which would run without the resource limits.
+func NewReadOnlyWorkspace() (*Workspace, error) {
+	var err error
+
+	// a typo would otherwise fail every ContainerCreate
+	err = checkStopSignal(stopSignal)
+	if err != nil {
+		return nil, err
+	}
+
+	strStopTimeout := os.Getenv("STOP_TIMEOUT")
+	if len(strStopTimeout) != 0 {
+		stopTimeout, err = time.ParseDuration(strStopTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stop timeout: %w", err)
+		}
+		if stopTimeout < 0 {
+			return nil, fmt.Errorf("invalid stop timeout: %s", stopTimeout)
+		}
+	}
+
+	strRemoveVolumes, ok := os.LookupEnv("REMOVE_VOLUMES")
+	if ok {
+		removeVolumes, err = strconv.ParseBool(strRemoveVolumes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remove volumes: %w", err)
+		}
+	}
+
+	strOperationTimeout := os.Getenv("DOCKER_TIMEOUT")
+	if len(strOperationTimeout) != 0 {
+		operationTimeout, err = time.ParseDuration(strOperationTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid docker timeout: %w", err)
+		}
+		if operationTimeout < 0 {
+			return nil, fmt.Errorf("invalid docker timeout: %s", operationTimeout)
+		}
+	}
+
+	strOperationSLO := os.Getenv("DOCKER_SLO")
+	if len(strOperationSLO) != 0 {
+		operationSLO, err = time.ParseDuration(strOperationSLO)
+		if err != nil {
+			return nil, fmt.Errorf("invalid docker slo: %w", err)
+		}
+		if operationSLO < 0 {
+			return nil, fmt.Errorf("invalid docker slo: %s", operationSLO)
+		}
+	}
+
+	namePrefix := os.Getenv("NAME_PREFIX")
+	if len(namePrefix) != 0 {
+		if !namePrefixExpression.MatchString(namePrefix) {
+			return nil, fmt.Errorf("invalid name prefix: %s", namePrefix)
+		}
+		containerNamePrefix = namePrefix + "-"
+	}
+
+	readinessProbe := os.Getenv("READINESS_PROBE")
+	if len(readinessProbe) != 0 {
+		readinessCmd, err = parseReadinessProbe(readinessProbe)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	strReadinessTimeout := os.Getenv("READINESS_TIMEOUT")
+	if len(strReadinessTimeout) != 0 {
+		readinessTimeout, err = time.ParseDuration(strReadinessTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid readiness timeout: %w", err)
+		}
+		if readinessTimeout <= 0 {
+			return nil, fmt.Errorf("invalid readiness timeout: %s", readinessTimeout)
+		}
+	}
+
+	strAdmissionMinFreeMemory := os.Getenv("ADMISSION_MIN_FREE_MEMORY")
+	if len(strAdmissionMinFreeMemory) != 0 {
+		floatMinFreeMemory, err := strconv.ParseFloat(strAdmissionMinFreeMemory, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid admission min free memory: %w", err)
+		}
+
+		SetAdmissionController(&MemoryAdmissionController{
+			MinFreeMemory: int64(floatMinFreeMemory * 1e6),
+		})
+	}
+
+	strAllowUnlimitedRootless := os.Getenv("ALLOW_UNLIMITED_ROOTLESS")
+	if len(strAllowUnlimitedRootless) != 0 {
+		allowUnlimitedRootless, err = strconv.ParseBool(strAllowUnlimitedRootless)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow unlimited rootless: %w", err)
+		}
+	}
+
+	strCleanEnv := os.Getenv("CLEAN_ENV")
+	if len(strCleanEnv) != 0 {
+		cleanEnv, err = strconv.ParseBool(strCleanEnv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clean env: %w", err)
+		}
+	}
+
+	envAllowlist, err = parseEnvAllowlist(os.Getenv("ENV_ALLOWLIST"))
+	if err != nil {
+		return nil, err
+	}
+
+	containerLabels, err = parseLabels(os.Getenv("CONTAINER_LABELS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid container labels: %w", err)
+	}
+
+	requiredLabels, err := parseLabels(os.Getenv("LABEL_POLICY_REQUIRED"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid required labels: %w", err)
+	}
+
+	forbiddenLabels, err := parseLabels(os.Getenv("LABEL_POLICY_FORBIDDEN"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid forbidden labels: %w", err)
+	}
+	SetLabelPolicy(requiredLabels, forbiddenLabels)
+
+	storageQuota = os.Getenv("STORAGE_QUOTA")
+	if len(storageQuota) != 0 {
+		_, err = units.RAMInBytes(storageQuota)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage quota: %w", err)
+		}
+	}
+
+	strRandomSeed := os.Getenv("RANDOM_SEED")
+	if len(strRandomSeed) != 0 {
+		randomSeed, err = parseRandomSeed(strRandomSeed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	strProvisionConcurrency := os.Getenv("PROVISION_CONCURRENCY")
+	if len(strProvisionConcurrency) != 0 {
+		provisionConcurrency, err := strconv.Atoi(strProvisionConcurrency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid provision concurrency: %w", err)
+		}
+		if provisionConcurrency <= 0 {
+			return nil, fmt.Errorf("invalid provision concurrency: %d", provisionConcurrency)
+		}
+
+		setProvisionConcurrency(provisionConcurrency)
+	}
+
+	strMemoryOvercommitRatio := os.Getenv("MEMORY_OVERCOMMIT_RATIO")
+	if len(strMemoryOvercommitRatio) != 0 {
+		memoryOvercommitRatio, err = strconv.ParseFloat(strMemoryOvercommitRatio, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory overcommit ratio: %w", err)
+		}
+		if memoryOvercommitRatio <= 0 {
+			return nil, fmt.Errorf("invalid memory overcommit ratio: %g", memoryOvercommitRatio)
+		}
+	}
+
+	strDaemonRateLimit := os.Getenv("DOCKER_RATE_LIMIT")
+	if len(strDaemonRateLimit) != 0 {
+		daemonRateLimit, err := strconv.ParseFloat(strDaemonRateLimit, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid docker rate limit: %w", err)
+		}
+		if daemonRateLimit <= 0 {
+			return nil, fmt.Errorf("invalid docker rate limit: %g", daemonRateLimit)
+		}
+
+		daemonRateBurst := int(math.Ceil(daemonRateLimit))
+		strDaemonRateBurst := os.Getenv("DOCKER_RATE_BURST")
+		if len(strDaemonRateBurst) != 0 {
+			daemonRateBurst, err = strconv.Atoi(strDaemonRateBurst)
+			if err != nil {
+				return nil, fmt.Errorf("invalid docker rate burst: %w", err)
+			}
+			if daemonRateBurst <= 0 {
+				return nil, fmt.Errorf("invalid docker rate burst: %d", daemonRateBurst)
+			}
+		}
+
+		setDaemonRateLimit(daemonRateLimit, daemonRateBurst)
+	}
+
+	registryFallbacks, err = parseRegistryFallbacks(os.Getenv("IMAGE_REGISTRY_FALLBACKS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image registry fallbacks: %w", err)
+	}
+
+	gitCloneURL := os.Getenv("GIT_CLONE_URL")
+	if len(gitCloneURL) != 0 {
+		gitClone, err = newGitCloneConfig(gitCloneURL, os.Getenv("GIT_CLONE_BRANCH"), os.Getenv("GIT_CLONE_PATH"), os.Getenv("GIT_CLONE_TOKEN"))
+		if err != nil {
+			return nil, err
+		}
+
+		gitCloneSSHKey := os.Getenv("GIT_CLONE_SSH_KEY")
+		if len(gitCloneSSHKey) != 0 {
+			err = gitClone.setSSHKey(gitCloneSSHKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		strGitCloneTimeout := os.Getenv("GIT_CLONE_TIMEOUT")
+		if len(strGitCloneTimeout) != 0 {
+			gitCloneTimeout, err = time.ParseDuration(strGitCloneTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid git clone timeout: %w", err)
+			}
+			if gitCloneTimeout <= 0 {
+				return nil, fmt.Errorf("invalid git clone timeout: %s", gitCloneTimeout)
+			}
+		}
+	}
+
+	strTimezone := os.Getenv("TIMEZONE")
+	if strTimezone == hostTimezone {
+		err = SetTimezoneFromHost()
+	} else {
+		err = SetTimezone(strTimezone)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	return &Workspace{}, nil
 }
 
-func (w *Workspace) Create(ctx context.Context, userName values.UserName) (*domain.Workspace, error) {
-	ctnName := containerName(userName)
+// checkStopSignal validates the signal name(with or without SIG) or number. Empty means the image default.
+func checkStopSignal(strSignal string) error {
+	if len(strSignal) == 0 {
+		return nil
+	}
+
+	sig, err := signal.ParseSignal(strSignal)
+	if err != nil || !signal.ValidSignalForPlatform(sig) {
+		return fmt.Errorf("invalid stop signal: %s", strSignal)
+	}
+
+	return nil
+}
+
+func createContainer(ctx context.Context, ctnName string) (container.ContainerCreateCreatedBody, error) {
+	labels := map[string]string{}
+	for key, value := range containerLabels {
+		labels[key] = value
+	}
+	labels[schemaVersionLabel] = strconv.Itoa(schemaVersion)
+	labels[appLabel] = appLabelValue
+
+	err := checkLabelPolicy(ctx, labels)
+	if err != nil {
+		return container.ContainerCreateCreatedBody{}, err
+	}
+
+	cancelReservation, err := reserveMemory(ctx, ctnName)
+	if err != nil {
+		return container.ContainerCreateCreatedBody{}, err
+	}
+
+	release, err := acquireProvision(ctx)
+	if err != nil {
+		cancelReservation()
+		return container.ContainerCreateCreatedBody{}, err
+	}
+	defer release()
+
+	var containerStopTimeout *int
+	if stopTimeoutSupported {
+		stopTimeoutSeconds := int(stopTimeout.Seconds())
+		containerStopTimeout = &stopTimeoutSeconds
+	}
+
+	ctx, cancel := operationContext(ctx, "ContainerCreate")
+	defer cancel()
+
 	res, err := cli.ContainerCreate(ctx, &container.Config{
-		Image: imageRef,
-		User:  imageUser,
-		Tty:   true,
+		Image:       imageRef,
+		User:        imageUser,
+		Tty:         true,
+		StopSignal:  stopSignal,
+		StopTimeout: containerStopTimeout,
+		Labels:      labels,
+		Env:         timezoneEnv(),
 	}, &container.HostConfig{
 		Resources: container.Resources{
 			NanoCPUs: cpuLimit,
 			Memory:   memoryLimit,
 		},
+		Runtime:    containerRuntime,
+		StorageOpt: storageOpt(),
 	}, nil, nil, ctnName)
+	// a conflicting container exists, so its reservation is kept
+	if err != nil && !errdefs.IsConflict(err) {
+		cancelReservation()
+	}
+
+	return res, err
+}
+
+// Create creates the container of the user.
+// Concurrent calls for the same user share a single creation run with the context of the first caller.
+func (w *Workspace) Create(ctx context.Context, userName values.UserName) (*domain.Workspace, error) {
+	ctnName := containerName(userName)
+
+	return creates.do(ctnName, func() (*domain.Workspace, error) {
+		return create(ctx, userName, ctnName)
+	})
+}
+
+func create(ctx context.Context, userName values.UserName, ctnName string) (*domain.Workspace, error) {
+	res, err := createContainer(ctx, ctnName)
 	if errdefs.IsConflict(err) {
-		ctnInfo, err := cli.ContainerInspect(ctx, ctnName)
+		ctnInfo, err := inspectContainer(ctx, ctnName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to inspect container: %w", err)
 		}
 
+		if ctnInfo.Config != nil {
+			version := containerSchemaVersion(ctnInfo.Config.Labels)
+			if version < schemaVersion {
+				log.Printf("container %s has outdated schema version %d(current: %d), reset it to upgrade\n", ctnName, version, schemaVersion)
+			}
+		}
+
 		workspaceID := values.NewWorkspaceID(ctnInfo.ID)
 		workspaceName := values.NewWorkspaceName(ctnName)
 		containerCounter.WithLabelValues(downLabel).Inc()
@@ -80,7 +445,7 @@ func (w *Workspace) Create(ctx context.Context, userName values.UserName) (*doma
 		return domain.NewWorkspace(workspaceID, workspaceName, userName), nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+		return nil, fmt.Errorf("failed to create container: %w", unsupportedFeatureError(err))
 	}
 
 	workspaceID := values.NewWorkspaceID(res.ID)
@@ -91,10 +456,51 @@ func (w *Workspace) Create(ctx context.Context, userName values.UserName) (*doma
 }
 
 func (w *Workspace) Start(ctx context.Context, workspace *domain.Workspace) error {
-	err := cli.ContainerStart(ctx, string(workspace.ID()), types.ContainerStartOptions{})
-	if err != nil && !errdefs.IsConflict(err) {
+	err := domain.ValidateTransition(workspace.Status, values.StatusUp)
+	if err != nil {
+		return err
+	}
+
+	err = admit(ctx, string(workspace.ID()), string(workspace.Name()))
+	if err != nil {
+		return err
+	}
+
+	opCtx, cancel := operationContext(ctx, "ContainerStart")
+	err = cli.ContainerStart(opCtx, string(workspace.ID()), types.ContainerStartOptions{})
+	cancel()
+	if isImageNotFound(err) {
+		return imageNotFoundError(err)
+	}
+	if isCancelled(err) {
+		// the daemon may have started the container after the request was cancelled
+		stopErr := stopContainer(context.Background(), string(workspace.ID()))
+		if stopErr != nil {
+			log.Printf("failed to stop container after cancelled start: %+v\n", stopErr)
+		}
+
 		return fmt.Errorf("failed to start container: %w", err)
 	}
+	if err != nil && !errdefs.IsConflict(err) {
+		return fmt.Errorf("failed to start container: %w", unsupportedFeatureError(resourceExhaustedError(err)))
+	}
+
+	err = waitReady(ctx, string(workspace.ID()))
+	if err != nil {
+		// the workspace is left down so that the next Start probes it again
+		stopErr := stopContainer(context.Background(), string(workspace.ID()))
+		if stopErr != nil {
+			log.Printf("failed to stop unready container: %+v\n", stopErr)
+		}
+
+		return fmt.Errorf("failed to wait for container: %w", err)
+	}
+
+	goRecovered("git_clone", func() {
+		cloneRepository(string(workspace.ID()))
+	})
+
+	workspace.SetStopping(false)
 	workspace.Status = values.StatusUp
 	containerCounter.WithLabelValues(downLabel).Dec()
 	containerCounter.WithLabelValues(upLabel).Inc()
@@ -102,9 +508,26 @@ func (w *Workspace) Start(ctx context.Context, workspace *domain.Workspace) erro
 	return nil
 }
 
+func stopContainer(ctx context.Context, containerID string) error {
+	// the daemon waits stopTimeout before killing the container
+	ctx, cancel := timeoutContext(ctx, "ContainerStop", operationTimeout+stopTimeout)
+	defer cancel()
+
+	return cli.ContainerStop(ctx, containerID, &stopTimeout)
+}
+
 func (w *Workspace) Stop(ctx context.Context, workspace *domain.Workspace) error {
-	err := cli.ContainerStop(ctx, string(workspace.ID()), &stopTimeout)
+	err := domain.ValidateTransition(workspace.Status, values.StatusDown)
+	if err != nil {
+		return err
+	}
+
+	// marked before the request since the die event can arrive before the response
+	workspace.SetStopping(true)
+
+	err = stopContainer(ctx, string(workspace.ID()))
 	if err != nil {
+		workspace.SetStopping(false)
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
 	workspace.Status = values.StatusDown
@@ -114,30 +537,34 @@ func (w *Workspace) Stop(ctx context.Context, workspace *domain.Workspace) error
 	return nil
 }
 
-func (w *Workspace) Recreate(ctx context.Context, workspace *domain.Workspace) (*domain.Workspace, error) {
-	err := cli.ContainerRemove(ctx, string(workspace.ID()), types.ContainerRemoveOptions{
-		Force: true,
+func removeContainer(ctx context.Context, containerID string, isRemoveVolumes bool) error {
+	ctx, cancel := operationContext(ctx, "ContainerRemove")
+	defer cancel()
+
+	return cli.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
+		RemoveVolumes: isRemoveVolumes,
+		Force:         true,
 	})
-	if err != nil {
+}
+
+func (w *Workspace) Recreate(ctx context.Context, workspace *domain.Workspace) (*domain.Workspace, error) {
+	// removed by name so that a container left by a cancelled Recreate is also replaced.
+	// the volumes are kept so that the data of the user survives the recreation
+	err := removeContainer(ctx, string(workspace.Name()), false)
+	if err != nil && !errdefs.IsNotFound(err) {
 		return nil, fmt.Errorf("failed to remove container: %w", err)
 	}
+	diskUsageCache.Delete(workspace.UserName())
+	memoryAllocation.Release(string(workspace.Name()))
+	workspace.Status = values.StatusRemoved
 	containerCounter.WithLabelValues(upLabel).Dec()
 	containerCounter.WithLabelValues(downLabel).Inc()
 
 	userName := workspace.UserName()
 	ctnName := string(workspace.Name())
-	res, err := cli.ContainerCreate(ctx, &container.Config{
-		Image: imageRef,
-		User:  imageUser,
-		Tty:   true,
-	}, &container.HostConfig{
-		Resources: container.Resources{
-			NanoCPUs: cpuLimit,
-			Memory:   memoryLimit,
-		},
-	}, nil, nil, ctnName)
+	res, err := createContainer(ctx, ctnName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+		return nil, fmt.Errorf("failed to create container: %w", unsupportedFeatureError(err))
 	}
 	containerCounter.WithLabelValues(downLabel).Dec()
 	containerCounter.WithLabelValues(upLabel).Inc()
@@ -147,3 +574,162 @@ func (w *Workspace) Recreate(ctx context.Context, workspace *domain.Workspace) (
 
 	return domain.NewWorkspace(workspaceID, workspaceName, userName), nil
 }
+
+func inspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	ctx, cancel := operationContext(ctx, "ContainerInspect")
+	defer cancel()
+
+	return cli.ContainerInspect(ctx, containerID)
+}
+
+func (w *Workspace) Get(ctx context.Context, userName values.UserName) (*domain.Workspace, error) {
+	ctnName := containerName(userName)
+	ctnInfo, err := inspectContainer(ctx, ctnName)
+	if errdefs.IsNotFound(err) {
+		return nil, workspace.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	workspaceID := values.NewWorkspaceID(ctnInfo.ID)
+	workspaceName := values.NewWorkspaceName(ctnName)
+	ws := domain.NewWorkspace(workspaceID, workspaceName, userName)
+	if ctnInfo.State != nil && ctnInfo.State.Running {
+		ws.Status = values.StatusUp
+	}
+
+	return ws, nil
+}
+
+func listUserContainers(ctx context.Context) ([]types.Container, error) {
+	ctx, cancel := operationContext(ctx, "ContainerList")
+	defer cancel()
+
+	ctns, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("name", "^/"+regexp.QuoteMeta(containerNamePrefix))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	return ctns, nil
+}
+
+func workspaceFromContainer(ctn types.Container) (*domain.Workspace, bool) {
+	for _, ctnName := range ctn.Names {
+		userName, ok := userNameFromContainerName(ctnName)
+		if !ok {
+			continue
+		}
+
+		workspaceID := values.NewWorkspaceID(ctn.ID)
+		workspaceName := values.NewWorkspaceName(containerName(userName))
+		ws := domain.NewWorkspace(workspaceID, workspaceName, userName)
+		if ctn.State == "running" {
+			ws.Status = values.StatusUp
+		}
+
+		return ws, true
+	}
+
+	return nil, false
+}
+
+func (w *Workspace) List(ctx context.Context) ([]*domain.Workspace, error) {
+	ctns, err := listUserContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]*domain.Workspace, 0, len(ctns))
+	for _, ctn := range ctns {
+		ws, ok := workspaceFromContainer(ctn)
+		if ok {
+			workspaces = append(workspaces, ws)
+		}
+	}
+
+	return workspaces, nil
+}
+
+func (w *Workspace) Remove(ctx context.Context, workspace *domain.Workspace) error {
+	err := domain.ValidateTransition(workspace.Status, values.StatusRemoved)
+	if err != nil {
+		return err
+	}
+
+	err = removeContainer(ctx, string(workspace.ID()), removeVolumes)
+	if err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	diskUsageCache.Delete(workspace.UserName())
+	memoryAllocation.Release(string(workspace.Name()))
+
+	if workspace.Status == values.StatusUp {
+		containerCounter.WithLabelValues(upLabel).Dec()
+	} else {
+		containerCounter.WithLabelValues(downLabel).Dec()
+	}
+	workspace.Status = values.StatusRemoved
+
+	return nil
+}
+
+func (w *Workspace) Stats(ctx context.Context, workspace *domain.Workspace) (*values.WorkspaceStats, error) {
+	// the body is read before the context is canceled
+	ctx, cancel := operationContext(ctx, "ContainerStats")
+	defer cancel()
+
+	res, err := cli.ContainerStats(ctx, string(workspace.ID()), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	var stats types.StatsJSON
+	err = json.NewDecoder(res.Body).Decode(&stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	var cpuPercent float64
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpuPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	return values.NewWorkspaceStats(cpuPercent, memoryUsage(stats.MemoryStats), stats.MemoryStats.Limit), nil
+}
+
+// memoryUsage excludes the page cache from the usage in the same way as docker stats,
+// since the kernel reclaims it before the container is OOM killed.
+func memoryUsage(memoryStats types.MemoryStats) uint64 {
+	cache, ok := memoryStats.Stats["cache"] // cgroup v1
+	if !ok {
+		cache = memoryStats.Stats["inactive_file"] // cgroup v2
+	}
+	if cache > memoryStats.Usage {
+		return 0
+	}
+
+	return memoryStats.Usage - cache
+}
+
+func (w *Workspace) DaemonVersion(ctx context.Context) (string, error) {
+	ctx, cancel := operationContext(ctx, "ServerVersion")
+	defer cancel()
+
+	serverVersion, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	return serverVersion.Version, nil
+}
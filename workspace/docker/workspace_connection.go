@@ -2,10 +2,13 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/mazrean/separated-webshell/domain"
 	"github.com/mazrean/separated-webshell/domain/values"
 )
@@ -25,27 +28,51 @@ var (
 	}
 )
 
+// sigkillExitCode exit code of a process killed by SIGKILL(128+9)
+const sigkillExitCode = 137
+
 type WorkspaceConnection struct{}
 
 func NewWorkspaceConnection() *WorkspaceConnection {
 	return &WorkspaceConnection{}
 }
 
-func (wc *WorkspaceConnection) Connect(ctx context.Context, workspace *domain.Workspace) (*domain.WorkspaceConnection, error) {
-	idRes, err := cli.ContainerExecCreate(ctx, string(workspace.ID()), createOpts)
+func (wc *WorkspaceConnection) Connect(ctx context.Context, workspace *domain.Workspace, term string) (*domain.WorkspaceConnection, error) {
+	err := checkResourceExhausted()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := execConfig(ctx, string(workspace.ID()), term)
+	if err != nil {
+		return nil, err
+	}
+
+	execCreateStartedAt := time.Now()
+	opCtx, cancel := operationContext(ctx, "ContainerExecCreate")
+	idRes, err := cli.ContainerExecCreate(opCtx, string(workspace.ID()), config)
+	cancel()
+	execCreateDuration := time.Since(execCreateStartedAt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create exec: %w", err)
+		return nil, fmt.Errorf("failed to create exec: %w", resourceExhaustedError(err))
 	}
 
+	// the attach is not bounded by the operation timeout since the hijacked connection outlives the call
+	execAttachStartedAt := time.Now()
 	stream, err := cli.ContainerExecAttach(ctx, idRes.ID, attachOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to attach container: %w", err)
+		return nil, fmt.Errorf("failed to attach container: %w", resourceExhaustedError(err))
 	}
+	execAttachDuration := time.Since(execAttachStartedAt)
 
 	connectionID := values.NewWorkspaceConnectionID(idRes.ID)
 	connectionIO := values.NewWorkspaceIO(stream.Conn, io.NopCloser(stream.Reader))
 
-	return domain.NewWorkspaceConnection(connectionID, connectionIO), nil
+	workspaceConnection := domain.NewWorkspaceConnection(connectionID, connectionIO)
+	workspaceConnection.Timing.ExecCreateDuration = execCreateDuration
+	workspaceConnection.Timing.ExecAttachDuration = execAttachDuration
+
+	return workspaceConnection, nil
 }
 
 func (wc *WorkspaceConnection) Disconnect(ctx context.Context, connection *domain.WorkspaceConnection) error {
@@ -63,6 +90,9 @@ func (wc *WorkspaceConnection) Disconnect(ctx context.Context, connection *domai
 }
 
 func (wc *WorkspaceConnection) Resize(ctx context.Context, connection *domain.WorkspaceConnection, window *values.Window) error {
+	ctx, cancel := operationContext(ctx, "ContainerExecResize")
+	defer cancel()
+
 	err := cli.ContainerExecResize(ctx, string(connection.ID()), types.ResizeOptions{
 		Height: window.Height(),
 		Width:  window.Width(),
@@ -73,3 +103,54 @@ func (wc *WorkspaceConnection) Resize(ctx context.Context, connection *domain.Wo
 
 	return nil
 }
+
+func (wc *WorkspaceConnection) IsOOMKilled(ctx context.Context, workspace *domain.Workspace, connection *domain.WorkspaceConnection) (bool, error) {
+	opCtx, cancel := operationContext(ctx, "ContainerExecInspect")
+	execInfo, err := cli.ContainerExecInspect(opCtx, string(connection.ID()))
+	cancel()
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if execInfo.Running || execInfo.ExitCode != sigkillExitCode {
+		return false, nil
+	}
+
+	ctnInfo, err := inspectContainer(ctx, string(workspace.ID()))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if ctnInfo.State == nil || !ctnInfo.State.OOMKilled {
+		return false, nil
+	}
+
+	// OOMKilled remains set until the container restarts, so the OOM must have happened during the connection
+	return oomKilledSince(ctx, string(workspace.ID()), connection.CreatedAt())
+}
+
+// oomKilledSince reports whether the container had an OOM event since the time.
+func oomKilledSince(ctx context.Context, containerID string, since time.Time) (bool, error) {
+	// bounded by until, so the event stream ends with io.EOF
+	ctx, cancel := operationContext(ctx, "Events")
+	defer cancel()
+
+	msgCh, errCh := cli.Events(ctx, types.EventsOptions{
+		Since: since.UTC().Format(time.RFC3339Nano),
+		Until: time.Now().UTC().Format(time.RFC3339Nano),
+		Filters: filters.NewArgs(
+			filters.Arg("type", "container"),
+			filters.Arg("container", containerID),
+			filters.Arg("event", "oom"),
+		),
+	})
+
+	select {
+	case <-msgCh:
+		return true, nil
+	case err := <-errCh:
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get oom events: %w", err)
+	}
+}
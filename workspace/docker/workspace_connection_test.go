@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsOOMKilled(t *testing.T) {
+	tests := []struct {
+		description string
+		execRunning bool
+		exitCode    int
+		oomKilled   bool
+		// oomEvent whether the container had an oom event during the connection
+		oomEvent bool
+		expected bool
+	}{
+		{
+			description: "oom killed",
+			exitCode:    sigkillExitCode,
+			oomKilled:   true,
+			oomEvent:    true,
+			expected:    true,
+		},
+		{
+			description: "killed after oom in previous session",
+			exitCode:    sigkillExitCode,
+			oomKilled:   true,
+			oomEvent:    false,
+			expected:    false,
+		},
+		{
+			description: "normal exit",
+			exitCode:    0,
+			oomKilled:   false,
+			expected:    false,
+		},
+		{
+			description: "killed without oom",
+			exitCode:    sigkillExitCode,
+			oomKilled:   false,
+			expected:    false,
+		},
+		{
+			description: "normal exit after oom in other session",
+			exitCode:    0,
+			oomKilled:   true,
+			expected:    false,
+		},
+		{
+			description: "exec still running",
+			execRunning: true,
+			oomKilled:   true,
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/exec/exec_id/json"):
+					writeJSON(t, w, types.ContainerExecInspect{
+						ExecID:      "exec_id",
+						ContainerID: "container_id",
+						Running:     test.execRunning,
+						ExitCode:    test.exitCode,
+					})
+				case strings.HasSuffix(r.URL.Path, "/events"):
+					assert.NotEmpty(t, r.URL.Query().Get("since"))
+					assert.NotEmpty(t, r.URL.Query().Get("until"))
+					assert.Contains(t, r.URL.Query().Get("filters"), "container_id")
+
+					if test.oomEvent {
+						writeJSON(t, w, events.Message{
+							Type:   events.ContainerEventType,
+							Action: "oom",
+							Actor:  events.Actor{ID: "container_id"},
+						})
+					}
+				case strings.HasSuffix(r.URL.Path, "/containers/container_id/json"):
+					writeJSON(t, w, types.ContainerJSON{
+						ContainerJSONBase: &types.ContainerJSONBase{
+							ID: "container_id",
+							State: &types.ContainerState{
+								OOMKilled: test.oomKilled,
+							},
+						},
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			workspace := domain.NewWorkspace("container_id", "user-test", "test")
+			connection := domain.NewWorkspaceConnection("exec_id", values.NewWorkspaceIO(nil, nil))
+
+			isOOMKilled, err := NewWorkspaceConnection().IsOOMKilled(context.Background(), workspace, connection)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, isOOMKilled)
+		})
+	}
+}
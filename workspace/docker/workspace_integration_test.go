@@ -0,0 +1,280 @@
+//go:build integration
+// +build integration
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+const defaultIntegrationImage = "alpine:3.14"
+
+// setupIntegration connects to the docker daemon specified by the DOCKER_* environment variables.
+func setupIntegration(t *testing.T) {
+	t.Helper()
+
+	testCli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("failed to create docker client: %s", err)
+	}
+
+	ctx := context.Background()
+
+	testImage := os.Getenv("INTEGRATION_IMAGE")
+	if len(testImage) == 0 {
+		testImage = defaultIntegrationImage
+	}
+
+	reader, err := testCli.ImagePull(ctx, testImage, types.ImagePullOptions{})
+	if err != nil {
+		t.Fatalf("failed to pull image: %s", err)
+	}
+	_, err = io.Copy(io.Discard, reader)
+	if err != nil {
+		t.Fatalf("failed to pull image: %s", err)
+	}
+	reader.Close()
+
+	defaultCli, defaultImageRef, defaultCreateOpts := cli, imageRef, createOpts
+	cli = testCli
+	imageRef = testImage
+	createOpts.User = "root"
+	createOpts.WorkingDir = "/"
+	createOpts.Cmd = []string{"/bin/sh"}
+	t.Cleanup(func() {
+		cli, imageRef, createOpts = defaultCli, defaultImageRef, defaultCreateOpts
+		testCli.Close()
+	})
+}
+
+func newIntegrationUserName(t *testing.T) values.UserName {
+	t.Helper()
+
+	userName, err := values.NewUserName(fmt.Sprintf("it%d", time.Now().UnixNano()%1e12))
+	if err != nil {
+		t.Fatalf("failed to create user name: %s", err)
+	}
+
+	return userName
+}
+
+func removeIntegrationWorkspace(t *testing.T, w *Workspace, ws *domain.Workspace) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		err := w.Remove(context.Background(), ws)
+		if err != nil {
+			t.Logf("failed to remove workspace: %s", err)
+		}
+	})
+}
+
+// readUntil reads r until the output contains expected or the timeout expires.
+func readUntil(r io.Reader, expected string, timeout time.Duration) (string, bool) {
+	outputCh := make(chan string, 1)
+	go func() {
+		buf := &bytes.Buffer{}
+		p := make([]byte, 1024)
+		for {
+			n, err := r.Read(p)
+			buf.Write(p[:n])
+			if strings.Contains(buf.String(), expected) || err != nil {
+				outputCh <- buf.String()
+				return
+			}
+		}
+	}()
+
+	select {
+	case output := <-outputCh:
+		return output, strings.Contains(output, expected)
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+func TestIntegration(t *testing.T) {
+	setupIntegration(t)
+
+	t.Run("Lifecycle", testIntegrationLifecycle)
+	t.Run("Conflict", testIntegrationConflict)
+	t.Run("Resize", testIntegrationResize)
+	t.Run("Output", testIntegrationOutput)
+}
+
+func testIntegrationLifecycle(t *testing.T) {
+	ctx := context.Background()
+	w := &Workspace{}
+	wc := NewWorkspaceConnection()
+	userName := newIntegrationUserName(t)
+
+	ws, err := w.Create(ctx, userName)
+	if err != nil {
+		t.Fatalf("failed to create workspace: %s", err)
+	}
+	removeIntegrationWorkspace(t, w, ws)
+
+	err = w.Start(ctx, ws)
+	if err != nil {
+		t.Fatalf("failed to start workspace: %s", err)
+	}
+	assert.Equal(t, values.StatusUp, ws.Status)
+
+	connection, err := wc.Connect(ctx, ws, "")
+	if err != nil {
+		t.Fatalf("failed to connect workspace: %s", err)
+	}
+
+	_, err = io.WriteString(connection.WriteCloser(), "echo integration-$((1+1))\n")
+	assert.NoError(t, err)
+
+	output, ok := readUntil(connection.ReadCloser(), "integration-2", 10*time.Second)
+	assert.True(t, ok, "unexpected output: %q", output)
+
+	err = wc.Disconnect(ctx, connection)
+	assert.NoError(t, err)
+
+	err = w.Stop(ctx, ws)
+	assert.NoError(t, err)
+
+	actual, err := w.Get(ctx, userName)
+	assert.NoError(t, err)
+	assert.Equal(t, values.StatusDown, actual.Status)
+
+	err = w.Remove(ctx, ws)
+	assert.NoError(t, err)
+
+	_, err = w.Get(ctx, userName)
+	assert.ErrorIs(t, err, workspace.ErrWorkspaceNotFound)
+}
+
+func testIntegrationConflict(t *testing.T) {
+	ctx := context.Background()
+	w := &Workspace{}
+	userName := newIntegrationUserName(t)
+
+	ws, err := w.Create(ctx, userName)
+	if err != nil {
+		t.Fatalf("failed to create workspace: %s", err)
+	}
+	removeIntegrationWorkspace(t, w, ws)
+
+	conflicted, err := w.Create(ctx, userName)
+	assert.NoError(t, err)
+	assert.Equal(t, ws.ID(), conflicted.ID())
+	assert.Equal(t, ws.Name(), conflicted.Name())
+}
+
+func testIntegrationResize(t *testing.T) {
+	ctx := context.Background()
+	w := &Workspace{}
+	wc := NewWorkspaceConnection()
+	userName := newIntegrationUserName(t)
+
+	ws, err := w.Create(ctx, userName)
+	if err != nil {
+		t.Fatalf("failed to create workspace: %s", err)
+	}
+	removeIntegrationWorkspace(t, w, ws)
+
+	err = w.Start(ctx, ws)
+	if err != nil {
+		t.Fatalf("failed to start workspace: %s", err)
+	}
+
+	connection, err := wc.Connect(ctx, ws, "")
+	if err != nil {
+		t.Fatalf("failed to connect workspace: %s", err)
+	}
+	defer wc.Disconnect(ctx, connection)
+
+	err = wc.Resize(ctx, connection, values.NewWindow(40, 120))
+	assert.NoError(t, err)
+
+	_, err = io.WriteString(connection.WriteCloser(), "stty size\n")
+	assert.NoError(t, err)
+
+	output, ok := readUntil(connection.ReadCloser(), "40 120", 10*time.Second)
+	assert.True(t, ok, "unexpected output: %q", output)
+}
+
+func testIntegrationOutput(t *testing.T) {
+	tests := []struct {
+		description string
+		tty         bool
+	}{
+		{
+			description: "tty",
+			tty:         true,
+		},
+		{
+			description: "non-tty",
+			tty:         false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			ctx := context.Background()
+			w := &Workspace{}
+			wc := NewWorkspaceConnection()
+			userName := newIntegrationUserName(t)
+
+			defaultCreateOpts, defaultAttachOpts := createOpts, attachOpts
+			createOpts.Tty, attachOpts.Tty = test.tty, test.tty
+			defer func() {
+				createOpts, attachOpts = defaultCreateOpts, defaultAttachOpts
+			}()
+
+			ws, err := w.Create(ctx, userName)
+			if err != nil {
+				t.Fatalf("failed to create workspace: %s", err)
+			}
+			removeIntegrationWorkspace(t, w, ws)
+
+			err = w.Start(ctx, ws)
+			if err != nil {
+				t.Fatalf("failed to start workspace: %s", err)
+			}
+
+			connection, err := wc.Connect(ctx, ws, "")
+			if err != nil {
+				t.Fatalf("failed to connect workspace: %s", err)
+			}
+			defer wc.Disconnect(ctx, connection)
+
+			_, err = io.WriteString(connection.WriteCloser(), "echo integration-out-$((1+1)); echo integration-err-$((1+2)) >&2; exit\n")
+			assert.NoError(t, err)
+
+			if test.tty {
+				// stdout and stderr share the terminal, which also echoes the input
+				output, ok := readUntil(connection.ReadCloser(), "integration-err-3", 10*time.Second)
+				assert.True(t, ok, "unexpected output: %q", output)
+				assert.Contains(t, output, "integration-out-2")
+				return
+			}
+
+			// stdout and stderr are multiplexed without a terminal
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			_, err = stdcopy.StdCopy(stdout, stderr, connection.ReadCloser())
+			assert.NoError(t, err)
+			assert.Equal(t, "integration-out-2\n", stdout.String())
+			assert.Equal(t, "integration-err-3\n", stderr.String())
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/mazrean/separated-webshell/domain"
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		description string
+		statusCode  int
+		message     string
+		status      values.WorkspaceStatus
+		isErr       bool
+		err         error
+	}{
+		{
+			description: "start container",
+			statusCode:  http.StatusNoContent,
+			status:      values.StatusUp,
+		},
+		{
+			description: "already started",
+			statusCode:  http.StatusNotModified,
+			status:      values.StatusUp,
+		},
+		{
+			description: "image layers removed",
+			statusCode:  http.StatusNotFound,
+			message:     "No such image: sha256:0123456789abcdef",
+			status:      values.StatusDown,
+			isErr:       true,
+			err:         workspace.ErrImageNotFound,
+		},
+		{
+			description: "container not found",
+			statusCode:  http.StatusNotFound,
+			message:     "No such container: container_id",
+			status:      values.StatusDown,
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/containers/container_id/start") {
+					http.NotFound(w, r)
+					return
+				}
+
+				if test.message == "" {
+					w.WriteHeader(test.statusCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				writeJSON(t, w, errorResponse{Message: test.message})
+			}))
+
+			ws := domain.NewWorkspace("container_id", "user-test", "test")
+
+			err := (&Workspace{}).Start(context.Background(), ws)
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				} else {
+					assert.NotErrorIs(t, err, workspace.ErrImageNotFound)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.status, ws.Status)
+		})
+	}
+}
+
+func TestStartCancelled(t *testing.T) {
+	stopped := make(chan struct{}, 1)
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/container_id/start"):
+			// the client disconnects while the daemon is starting the container
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/containers/container_id/stop"):
+			stopped <- struct{}{}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	ws := domain.NewWorkspace("container_id", "user-test", "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := (&Workspace{}).Start(ctx, ws)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, values.StatusDown, ws.Status)
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("container is not stopped after cancelled start")
+	}
+}
+
+func TestInvalidTransition(t *testing.T) {
+	tests := []struct {
+		description string
+		status      values.WorkspaceStatus
+		operation   func(w *Workspace, ws *domain.Workspace) error
+	}{
+		{
+			description: "start up workspace",
+			status:      values.StatusUp,
+			operation: func(w *Workspace, ws *domain.Workspace) error {
+				return w.Start(context.Background(), ws)
+			},
+		},
+		{
+			description: "stop down workspace",
+			status:      values.StatusDown,
+			operation: func(w *Workspace, ws *domain.Workspace) error {
+				return w.Stop(context.Background(), ws)
+			},
+		},
+		{
+			description: "start removed workspace",
+			status:      values.StatusRemoved,
+			operation: func(w *Workspace, ws *domain.Workspace) error {
+				return w.Start(context.Background(), ws)
+			},
+		},
+		{
+			description: "remove removed workspace",
+			status:      values.StatusRemoved,
+			operation: func(w *Workspace, ws *domain.Workspace) error {
+				return w.Remove(context.Background(), ws)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				http.NotFound(w, r)
+			}))
+
+			ws := domain.NewWorkspace("container_id", "user-test", "test")
+			ws.Status = test.status
+
+			err := test.operation(&Workspace{}, ws)
+
+			var transitionErr *domain.ErrInvalidTransition
+			assert.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, test.status, ws.Status)
+		})
+	}
+}
+
+func TestRecreate(t *testing.T) {
+	tests := []struct {
+		description      string
+		removeStatusCode int
+		isErr            bool
+	}{
+		{
+			description:      "recreate container",
+			removeStatusCode: http.StatusNoContent,
+		},
+		{
+			description:      "container removed by cancelled recreate",
+			removeStatusCode: http.StatusNotFound,
+		},
+		{
+			description:      "remove error",
+			removeStatusCode: http.StatusInternalServerError,
+			isErr:            true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/containers/user-test"):
+					// the volumes are kept even if REMOVE_VOLUMES is set
+					assert.Empty(t, r.URL.Query().Get("v"))
+
+					if test.removeStatusCode == http.StatusNoContent {
+						w.WriteHeader(test.removeStatusCode)
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(test.removeStatusCode)
+					writeJSON(t, w, errorResponse{Message: "No such container: user-test"})
+				case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/containers/create"):
+					assert.Equal(t, "user-test", r.URL.Query().Get("name"))
+					w.WriteHeader(http.StatusCreated)
+					writeJSON(t, w, container.ContainerCreateCreatedBody{ID: "new_container_id"})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			defaultRemoveVolumes := removeVolumes
+			removeVolumes = true
+			defer func() {
+				removeVolumes = defaultRemoveVolumes
+			}()
+
+			ws := domain.NewWorkspace("container_id", "user-test", "test")
+
+			newWS, err := (&Workspace{}).Recreate(context.Background(), ws)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, values.WorkspaceID("new_container_id"), newWS.ID())
+			assert.Equal(t, ws.Name(), newWS.Name())
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		description string
+		memoryStats types.MemoryStats
+		usage       uint64
+	}{
+		{
+			description: "no cache",
+			memoryStats: types.MemoryStats{Usage: 100, Limit: 1000},
+			usage:       100,
+		},
+		{
+			description: "cgroup v1 cache",
+			memoryStats: types.MemoryStats{Usage: 900, Limit: 1000, Stats: map[string]uint64{"cache": 800}},
+			usage:       100,
+		},
+		{
+			description: "cgroup v2 inactive_file",
+			memoryStats: types.MemoryStats{Usage: 900, Limit: 1000, Stats: map[string]uint64{"inactive_file": 800}},
+			usage:       100,
+		},
+		{
+			description: "cache larger than usage",
+			memoryStats: types.MemoryStats{Usage: 100, Limit: 1000, Stats: map[string]uint64{"cache": 200}},
+			usage:       0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/containers/container_id/stats") {
+					http.NotFound(w, r)
+					return
+				}
+
+				writeJSON(t, w, types.StatsJSON{Stats: types.Stats{MemoryStats: test.memoryStats}})
+			}))
+
+			ws := domain.NewWorkspace("container_id", "user-test", "test")
+
+			stats, err := (&Workspace{}).Stats(context.Background(), ws)
+			assert.NoError(t, err)
+			assert.Equal(t, test.usage, stats.MemoryUsage())
+			assert.Equal(t, test.memoryStats.Limit, stats.MemoryLimit())
+		})
+	}
+}
+
+func TestCheckStopSignal(t *testing.T) {
+	tests := []struct {
+		description string
+		signal      string
+		isErr       bool
+	}{
+		{
+			description: "empty",
+			signal:      "",
+		},
+		{
+			description: "name",
+			signal:      "SIGHUP",
+		},
+		{
+			description: "name without SIG",
+			signal:      "hup",
+		},
+		{
+			description: "number",
+			signal:      "15",
+		},
+		{
+			description: "typo",
+			signal:      "SIGHUPP",
+			isErr:       true,
+		},
+		{
+			description: "zero",
+			signal:      "0",
+			isErr:       true,
+		},
+		{
+			description: "out of range",
+			signal:      "999",
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := checkStopSignal(test.signal)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDaemonVersion(t *testing.T) {
+	setupTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/version") {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(t, w, types.Version{Version: "20.10.7"})
+	}))
+
+	daemonVersion, err := (&Workspace{}).DaemonVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "20.10.7", daemonVersion)
+}
+
+func TestUserNameFromContainerName(t *testing.T) {
+	tests := []struct {
+		description string
+		prefix      string
+		ctnName     string
+		userName    values.UserName
+		ok          bool
+	}{
+		{
+			description: "default prefix",
+			prefix:      "user-",
+			ctnName:     "/user-test",
+			userName:    "test",
+			ok:          true,
+		},
+		{
+			description: "custom prefix",
+			prefix:      "staging-",
+			ctnName:     "/staging-test",
+			userName:    "test",
+			ok:          true,
+		},
+		{
+			description: "other prefix",
+			prefix:      "staging-",
+			ctnName:     "/user-test",
+			ok:          false,
+		},
+		{
+			description: "invalid user name",
+			prefix:      "user-",
+			ctnName:     "/user-",
+			ok:          false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defaultPrefix := containerNamePrefix
+			containerNamePrefix = test.prefix
+			defer func() {
+				containerNamePrefix = defaultPrefix
+			}()
+
+			userName, ok := userNameFromContainerName(test.ctnName)
+			assert.Equal(t, test.ok, ok)
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, test.userName, userName)
+			assert.Equal(t, test.ctnName, "/"+containerName(userName))
+		})
+	}
+}
@@ -51,6 +51,36 @@ func (mr *MockIWorkspaceMockRecorder) Create(ctx, userName interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIWorkspace)(nil).Create), ctx, userName)
 }
 
+// DaemonVersion mocks base method.
+func (m *MockIWorkspace) DaemonVersion(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DaemonVersion", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DaemonVersion indicates an expected call of DaemonVersion.
+func (mr *MockIWorkspaceMockRecorder) DaemonVersion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DaemonVersion", reflect.TypeOf((*MockIWorkspace)(nil).DaemonVersion), ctx)
+}
+
+// DetectStorageDriver mocks base method.
+func (m *MockIWorkspace) DetectStorageDriver(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectStorageDriver", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectStorageDriver indicates an expected call of DetectStorageDriver.
+func (mr *MockIWorkspaceMockRecorder) DetectStorageDriver(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectStorageDriver", reflect.TypeOf((*MockIWorkspace)(nil).DetectStorageDriver), ctx)
+}
+
 // Recreate mocks base method.
 func (m *MockIWorkspace) Recreate(ctx context.Context, workspace *domain.Workspace) (*domain.Workspace, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +110,21 @@ func (mr *MockIWorkspaceMockRecorder) Start(ctx, workspace interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockIWorkspace)(nil).Start), ctx, workspace)
 }
 
+// Stats mocks base method.
+func (m *MockIWorkspace) Stats(ctx context.Context, workspace *domain.Workspace) (*values.WorkspaceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx, workspace)
+	ret0, _ := ret[0].(*values.WorkspaceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockIWorkspaceMockRecorder) Stats(ctx, workspace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockIWorkspace)(nil).Stats), ctx, workspace)
+}
+
 // Stop mocks base method.
 func (m *MockIWorkspace) Stop(ctx context.Context, workspace *domain.Workspace) error {
 	m.ctrl.T.Helper()
@@ -93,3 +138,18 @@ func (mr *MockIWorkspaceMockRecorder) Stop(ctx, workspace interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockIWorkspace)(nil).Stop), ctx, workspace)
 }
+
+// WatchDied mocks base method.
+func (m *MockIWorkspace) WatchDied(ctx context.Context) (<-chan values.UserName, <-chan error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchDied", ctx)
+	ret0, _ := ret[0].(<-chan values.UserName)
+	ret1, _ := ret[1].(<-chan error)
+	return ret0, ret1
+}
+
+// WatchDied indicates an expected call of WatchDied.
+func (mr *MockIWorkspaceMockRecorder) WatchDied(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDied", reflect.TypeOf((*MockIWorkspace)(nil).WatchDied), ctx)
+}
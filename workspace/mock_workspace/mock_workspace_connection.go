@@ -37,18 +37,18 @@ func (m *MockIWorkspaceConnection) EXPECT() *MockIWorkspaceConnectionMockRecorde
 }
 
 // Connect mocks base method.
-func (m *MockIWorkspaceConnection) Connect(ctx context.Context, workspace *domain.Workspace) (*domain.WorkspaceConnection, error) {
+func (m *MockIWorkspaceConnection) Connect(ctx context.Context, workspace *domain.Workspace, term string) (*domain.WorkspaceConnection, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Connect", ctx, workspace)
+	ret := m.ctrl.Call(m, "Connect", ctx, workspace, term)
 	ret0, _ := ret[0].(*domain.WorkspaceConnection)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Connect indicates an expected call of Connect.
-func (mr *MockIWorkspaceConnectionMockRecorder) Connect(ctx, workspace interface{}) *gomock.Call {
+func (mr *MockIWorkspaceConnectionMockRecorder) Connect(ctx, workspace, term interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockIWorkspaceConnection)(nil).Connect), ctx, workspace)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockIWorkspaceConnection)(nil).Connect), ctx, workspace, term)
 }
 
 // Disconnect mocks base method.
@@ -65,6 +65,21 @@ func (mr *MockIWorkspaceConnectionMockRecorder) Disconnect(ctx, connection inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockIWorkspaceConnection)(nil).Disconnect), ctx, connection)
 }
 
+// IsOOMKilled mocks base method.
+func (m *MockIWorkspaceConnection) IsOOMKilled(ctx context.Context, workspace *domain.Workspace, connection *domain.WorkspaceConnection) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsOOMKilled", ctx, workspace, connection)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsOOMKilled indicates an expected call of IsOOMKilled.
+func (mr *MockIWorkspaceConnectionMockRecorder) IsOOMKilled(ctx, workspace, connection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsOOMKilled", reflect.TypeOf((*MockIWorkspaceConnection)(nil).IsOOMKilled), ctx, workspace, connection)
+}
+
 // Resize mocks base method.
 func (m *MockIWorkspaceConnection) Resize(ctx context.Context, connection *domain.WorkspaceConnection, window *values.Window) error {
 	m.ctrl.T.Helper()
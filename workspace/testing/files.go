@@ -0,0 +1,135 @@
+// Package testing provides helpers to assert the files in workspaces from tests of container environments.
+package testing
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+)
+
+// ErrContentMismatch the content of the file differs from the expected one.
+var ErrContentMismatch = errors.New("content mismatch error")
+
+// FileCopier copies files from workspaces as tar archives. *docker.Workspace implements it.
+type FileCopier interface {
+	CopyFromContainer(ctx context.Context, userName values.UserName, path string) (io.ReadCloser, error)
+}
+
+type Files struct {
+	fc FileCopier
+}
+
+func NewFiles(fc FileCopier) *Files {
+	return &Files{
+		fc: fc,
+	}
+}
+
+// AssertFileContent returns ErrContentMismatch if the content of the regular file at filePath differs from expectedContent.
+func (f *Files) AssertFileContent(ctx context.Context, userName values.UserName, filePath string, expectedContent string) error {
+	content, err := f.readFile(ctx, userName, filePath)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(content, []byte(expectedContent)) {
+		return fmt.Errorf("%w: %s: expected %q, actual %q", ErrContentMismatch, filePath, expectedContent, content)
+	}
+
+	return nil
+}
+
+// AssertFileExists reports whether a file or a directory exists at filePath.
+func (f *Files) AssertFileExists(ctx context.Context, userName values.UserName, filePath string) bool {
+	reader, err := f.fc.CopyFromContainer(ctx, userName, filePath)
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+
+	_, err = tar.NewReader(reader).Next()
+
+	return err == nil
+}
+
+// AssertDirectoryListing returns the sorted names of the entries directly under the directory at dirPath.
+// Names of directories end with "/".
+func (f *Files) AssertDirectoryListing(ctx context.Context, userName values.UserName, dirPath string) ([]string, error) {
+	reader, err := f.fc.CopyFromContainer(ctx, userName, dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from workspace: %w", err)
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+
+	// the archive of a directory has the directory itself as the first entry
+	root, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if root.Typeflag != tar.TypeDir {
+		return nil, fmt.Errorf("not a directory: %s", dirPath)
+	}
+	rootName := strings.TrimSuffix(root.Name, "/") + "/"
+
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := strings.TrimPrefix(header.Name, rootName)
+		if header.Typeflag == tar.TypeDir {
+			name = strings.TrimSuffix(name, "/") + "/"
+		}
+		if strings.Contains(strings.TrimSuffix(name, "/"), "/") {
+			continue
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (f *Files) readFile(ctx context.Context, userName values.UserName, filePath string) ([]byte, error) {
+	reader, err := f.fc.CopyFromContainer(ctx, userName, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from workspace: %w", err)
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrFileNotFound, filePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Typeflag != tar.TypeReg || header.Name != path.Base(filePath) {
+		return nil, fmt.Errorf("not a regular file: %s", filePath)
+	}
+
+	content, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return content, nil
+}
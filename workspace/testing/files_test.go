@@ -0,0 +1,155 @@
+package testing
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/mazrean/separated-webshell/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+type tarEntry struct {
+	name    string
+	content string
+	isDir   bool
+}
+
+// fakeCopier returns the archive of path in the same layout as the docker daemon.
+type fakeCopier map[string][]tarEntry
+
+func (fc fakeCopier) CopyFromContainer(ctx context.Context, userName values.UserName, path string) (io.ReadCloser, error) {
+	entries, ok := fc[path]
+	if !ok {
+		return nil, workspace.ErrFileNotFound
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Mode:     0o644,
+			Size:     int64(len(entry.content)),
+			Typeflag: tar.TypeReg,
+		}
+		if entry.isDir {
+			header.Mode = 0o755
+			header.Size = 0
+			header.Typeflag = tar.TypeDir
+		}
+
+		err := tw.WriteHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(tw, entry.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(buf), nil
+}
+
+var testCopier = fakeCopier{
+	"/home/ubuntu/a.txt": {
+		{name: "a.txt", content: "hello\n"},
+	},
+	"/home/ubuntu": {
+		{name: "ubuntu/", isDir: true},
+		{name: "ubuntu/a.txt", content: "hello\n"},
+		{name: "ubuntu/src/", isDir: true},
+		{name: "ubuntu/src/main.go", content: "package main\n"},
+		{name: "ubuntu/.bashrc", content: ""},
+	},
+}
+
+func TestAssertFileContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description     string
+		path            string
+		expectedContent string
+		err             error
+		isErr           bool
+	}{
+		{
+			description:     "same content",
+			path:            "/home/ubuntu/a.txt",
+			expectedContent: "hello\n",
+		},
+		{
+			description:     "different content",
+			path:            "/home/ubuntu/a.txt",
+			expectedContent: "hello",
+			err:             ErrContentMismatch,
+			isErr:           true,
+		},
+		{
+			description: "file not found",
+			path:        "/home/ubuntu/b.txt",
+			err:         workspace.ErrFileNotFound,
+			isErr:       true,
+		},
+		{
+			description: "directory",
+			path:        "/home/ubuntu",
+			isErr:       true,
+		},
+	}
+
+	files := NewFiles(testCopier)
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			err := files.AssertFileContent(context.Background(), "test", test.path, test.expectedContent)
+			if test.isErr {
+				assert.Error(t, err)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAssertFileExists(t *testing.T) {
+	t.Parallel()
+
+	files := NewFiles(testCopier)
+
+	assert.True(t, files.AssertFileExists(context.Background(), "test", "/home/ubuntu/a.txt"))
+	assert.True(t, files.AssertFileExists(context.Background(), "test", "/home/ubuntu"))
+	assert.False(t, files.AssertFileExists(context.Background(), "test", "/home/ubuntu/b.txt"))
+}
+
+func TestAssertDirectoryListing(t *testing.T) {
+	t.Parallel()
+
+	files := NewFiles(testCopier)
+
+	names, err := files.AssertDirectoryListing(context.Background(), "test", "/home/ubuntu")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".bashrc", "a.txt", "src/"}, names)
+
+	_, err = files.AssertDirectoryListing(context.Background(), "test", "/home/ubuntu/a.txt")
+	assert.Error(t, err)
+
+	_, err = files.AssertDirectoryListing(context.Background(), "test", "/home/ubuntu/b.txt")
+	assert.ErrorIs(t, err, workspace.ErrFileNotFound)
+}
@@ -0,0 +1,54 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+)
+
+// ScriptRunner runs shell scripts in workspaces. *docker.Workspace implements it.
+type ScriptRunner interface {
+	RunScript(ctx context.Context, userName values.UserName, script string, stdout io.Writer, stderr io.Writer) (int, error)
+}
+
+type Scripts struct {
+	sr ScriptRunner
+}
+
+func NewScripts(sr ScriptRunner) *Scripts {
+	return &Scripts{
+		sr: sr,
+	}
+}
+
+// RunScript runs script in the running workspace of the user with the shell of the image and waits for the completion up to timeout.
+// A non-zero exit code is not an error. The timeout is disabled if it is 0.
+func (s *Scripts) RunScript(ctx context.Context, userName values.UserName, script string, timeout time.Duration) (string, string, int, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	exitCode, err := s.sr.RunScript(ctx, userName, script, stdout, stderr)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to run script: %w", err)
+	}
+
+	return stdout.String(), stderr.String(), exitCode, nil
+}
+
+// MustRunScript is like RunScript but panics if the script could not be run.
+func (s *Scripts) MustRunScript(ctx context.Context, userName values.UserName, script string, timeout time.Duration) (string, string, int) {
+	stdout, stderr, exitCode, err := s.RunScript(ctx, userName, script, timeout)
+	if err != nil {
+		panic(err)
+	}
+
+	return stdout, stderr, exitCode
+}
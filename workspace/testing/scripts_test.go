@@ -0,0 +1,121 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mazrean/separated-webshell/domain/values"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRunner echoes the script to stdout and exits with the number of lines.
+type fakeRunner struct {
+	delay time.Duration
+	err   error
+}
+
+func (fr *fakeRunner) RunScript(ctx context.Context, userName values.UserName, script string, stdout io.Writer, stderr io.Writer) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(fr.delay):
+	}
+
+	if fr.err != nil {
+		return 0, fr.err
+	}
+
+	_, err := io.WriteString(stdout, script)
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.WriteString(stderr, "stderr")
+	if err != nil {
+		return 0, err
+	}
+
+	return strings.Count(script, "\n"), nil
+}
+
+func TestRunScript(t *testing.T) {
+	t.Parallel()
+
+	runErr := errors.New("run error")
+
+	tests := []struct {
+		description string
+		runner      *fakeRunner
+		timeout     time.Duration
+		stdout      string
+		stderr      string
+		exitCode    int
+		err         error
+		isErr       bool
+	}{
+		{
+			description: "run script",
+			runner:      &fakeRunner{},
+			timeout:     time.Second,
+			stdout:      "echo a\necho b\n",
+			stderr:      "stderr",
+			exitCode:    2,
+		},
+		{
+			description: "no timeout",
+			runner:      &fakeRunner{},
+			stdout:      "echo a\necho b\n",
+			stderr:      "stderr",
+			exitCode:    2,
+		},
+		{
+			description: "timeout",
+			runner:      &fakeRunner{delay: time.Second},
+			timeout:     10 * time.Millisecond,
+			err:         context.DeadlineExceeded,
+			isErr:       true,
+		},
+		{
+			description: "run error",
+			runner:      &fakeRunner{err: runErr},
+			timeout:     time.Second,
+			err:         runErr,
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			scripts := NewScripts(test.runner)
+
+			stdout, stderr, exitCode, err := scripts.RunScript(context.Background(), "test", "echo a\necho b\n", test.timeout)
+			if test.isErr {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.stdout, stdout)
+			assert.Equal(t, test.stderr, stderr)
+			assert.Equal(t, test.exitCode, exitCode)
+		})
+	}
+}
+
+func TestMustRunScript(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, exitCode := NewScripts(&fakeRunner{}).MustRunScript(context.Background(), "test", "true\n", time.Second)
+	assert.Equal(t, "true\n", stdout)
+	assert.Equal(t, 1, exitCode)
+
+	assert.Panics(t, func() {
+		NewScripts(&fakeRunner{err: errors.New("run error")}).MustRunScript(context.Background(), "test", "true\n", time.Second)
+	})
+}
@@ -12,6 +12,22 @@ import (
 var (
 	// ErrWorkspaceExist workspace already exists.
 	ErrWorkspaceExist = errors.New("workspace exist error")
+	// ErrWorkspaceNotFound workspace not found.
+	ErrWorkspaceNotFound = errors.New("workspace not found error")
+	// ErrFileNotFound the file does not exist in the workspace.
+	ErrFileNotFound = errors.New("file not found error")
+	// ErrImageNotFound the image of the workspace no longer exists.
+	ErrImageNotFound = errors.New("image not found error")
+	// ErrInsufficientMemory the memory limit of a new workspace exceeds the memory available for workspaces.
+	ErrInsufficientMemory = errors.New("insufficient memory error")
+	// ErrWorkspaceNotReady the workspace did not pass the readiness probe in time.
+	ErrWorkspaceNotReady = errors.New("workspace not ready error")
+	// ErrAdmissionDenied the workspace is not admitted on the current host load.
+	ErrAdmissionDenied = errors.New("admission denied error")
+	// ErrPolicyViolation the labels of the workspace violate the label policy.
+	ErrPolicyViolation = errors.New("policy violation error")
+	// ErrResourceExhausted the file descriptors of the host are exhausted.
+	ErrResourceExhausted = errors.New("resource exhausted error")
 )
 
 type IWorkspace interface {
@@ -19,4 +35,8 @@ type IWorkspace interface {
 	Start(ctx context.Context, workspace *domain.Workspace) error
 	Stop(ctx context.Context, workspace *domain.Workspace) error
 	Recreate(ctx context.Context, workspace *domain.Workspace) (*domain.Workspace, error)
+	Stats(ctx context.Context, workspace *domain.Workspace) (*values.WorkspaceStats, error)
+	DaemonVersion(ctx context.Context) (string, error)
+	DetectStorageDriver(ctx context.Context) (string, error)
+	WatchDied(ctx context.Context) (<-chan values.UserName, <-chan error)
 }
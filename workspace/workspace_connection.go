@@ -9,7 +9,10 @@ import (
 )
 
 type IWorkspaceConnection interface {
-	Connect(ctx context.Context, workspace *domain.Workspace) (*domain.WorkspaceConnection, error)
+	// Connect starts a shell in the workspace. term is the TERM of the shell, the default of the workspace if empty.
+	Connect(ctx context.Context, workspace *domain.Workspace, term string) (*domain.WorkspaceConnection, error)
 	Disconnect(ctx context.Context, connection *domain.WorkspaceConnection) error
 	Resize(ctx context.Context, connection *domain.WorkspaceConnection, window *values.Window) error
+	// IsOOMKilled reports whether the connection was terminated by the OOM killer.
+	IsOOMKilled(ctx context.Context, workspace *domain.Workspace, connection *domain.WorkspaceConnection) (bool, error)
 }